@@ -0,0 +1,43 @@
+// Command assetgc runs the orphaned upload collector once and prints its
+// report as JSON. It is a dry run unless -delete is given.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/assetgc"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/config"
+)
+
+func main() {
+	// A missing .env is fine here; the environment may already be set. It is
+	// loaded first so the defaults below match the server's collector.
+	_ = godotenv.Load()
+	opts := assetgc.OptionsFromEnv()
+	del := flag.Bool("delete", false, "delete the orphaned assets instead of only reporting them")
+	flag.DurationVar(&opts.Grace, "grace", opts.Grace, "how long an asset must have been unreferenced")
+	flag.IntVar(&opts.BatchSize, "limit", opts.BatchSize, "maximum number of assets to examine")
+	flag.Parse()
+	opts.DryRun = !*del
+
+	// Logs go to stderr so stdout is only the report
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	config.InitDB()
+	config.InitStorage()
+
+	report, err := assetgc.New(config.DB, config.Storage, opts).Run(context.Background())
+	if err != nil {
+		config.Fatal("Asset collection failed", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		config.Fatal("Failed to write report", err)
+	}
+}
@@ -1,12 +1,21 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/accounts"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/assetgc"
 	"github.com/shreyashsri79/vitbuddy-backend/internal/config"
-	"github.com/shreyashsri79/vitbuddy-backend/internal/controllers"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/middleware"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/openapi"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/validation"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 func main() {
@@ -16,47 +25,73 @@ func main() {
 		panic(err)
 	}
 
-	config.InitDB()
+	config.InitLogger()
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	if err := validation.Register(); err != nil {
+		config.Fatal("Failed to register validators", err)
 	}
 
-	r := gin.Default()
-
-	r.GET("/", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"message": "server is running",
-		})
-	})
+	shutdownTracing := config.InitTracing()
+	defer shutdownTracing(context.Background())
 
-	r.GET("/users/:id", controllers.GetUserByID)
-	r.POST("/users", controllers.CreateUser)
-	r.PUT("/users/:id", controllers.UpdateUser)
-
-	r.POST("/lostfound", controllers.CreateLostFound)
-	r.GET("/lostfound", controllers.GetLostFound)
-	r.PUT("/lostfound/:id", controllers.UpdateLostFound)
-	r.DELETE("/lostfound/:id", controllers.DeleteLostFound)
+	config.InitDB()
+	config.InitAuth()
+	config.InitWebhooks()
+	config.InitMailer()
+	config.InitAccounts()
+	config.InitStorage()
 
-	r.POST("/marketplace", controllers.CreateMarketplaceItem)
-	r.GET("/marketplace", controllers.GetMarketplaceItems)
-	r.PUT("/marketplace/:id", controllers.UpdateMarketplaceItem)
-	r.DELETE("/marketplace/:id", controllers.DeleteMarketplaceItem)
+	// Deletes uploads that were never attached or were detached long enough ago
+	assetgc.New(config.DB, config.Storage, assetgc.OptionsFromEnv()).Start(context.Background())
+	// Deletes accounts whose requested deletion is past its grace period
+	accounts.New(config.DB, accounts.IntervalFromEnv()).Start(context.Background())
 
-	r.POST("/delibuddy", controllers.CreateDelibuddy)
-	r.GET("/delibuddy", controllers.GetDelibuddy)
-	r.PUT("/delibuddy/:id", controllers.UpdateDelibuddy)
-	r.DELETE("/delibuddy/:id", controllers.DeleteDelibuddy)
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
 
-	r.POST("/cab", controllers.CreateCab)
-	r.GET("/cab", controllers.GetCabs)
-	r.PUT("/cab/:id", controllers.UpdateCab)
-	r.DELETE("/cab/:id", controllers.DeleteCab)
+	r := gin.New()
+	// The anonymous rate limit keys on the client IP, so it must not come from a forwarded header anyone can set
+	if err := r.SetTrustedProxies(middleware.TrustedProxiesFromEnv()); err != nil {
+		config.Fatal("Invalid TRUSTED_PROXIES", err)
+	}
+	r.Use(
+		otelgin.Middleware(config.ServiceName),
+		middleware.RequestID(),
+		middleware.RequestLogger(),
+		middleware.Recovery(),
+	)
+	r.HandleMethodNotAllowed = true
+	r.NoRoute(middleware.NoRoute)
+	r.NoMethod(middleware.NoMethod)
+
+	// Before the rate limiter so signed-in users are limited per user, not per IP
+	r.Use(middleware.Authenticate(config.Auth))
+
+	limits := middleware.NewMemoryStore(5 * time.Minute)
+	r.Use(middleware.RateLimit(limits, middleware.PolicyFromEnv("default", "120/1m")))
+
+	// Largest upload plus room for the multipart envelope; must run before the validator reads bodies
+	r.Use(middleware.BodyLimit(config.UploadLimits.MaxBytes + 64<<10))
+
+	spec, err := openapi.Load()
+	if err != nil {
+		config.Fatal("Failed to load OpenAPI spec", err)
+	}
+	validateRequest, err := openapi.Validator(spec)
+	if err != nil {
+		config.Fatal("Failed to build request validator", err)
+	}
+	r.Use(validateRequest)
+	openapi.Register(r, spec)
 
+	registerRoutes(r, limits)
 
-	r.POST("/upload", controllers.UploadImage)
+	// Every route must be documented, otherwise the validator would wave it through
+	if missing := openapi.MissingRoutes(spec, r.Routes()); len(missing) > 0 {
+		config.Fatal("Routes missing from OpenAPI spec", fmt.Errorf("%s", strings.Join(missing, ", ")))
+	}
 
 	r.Run(":" + port)
 
@@ -0,0 +1,37 @@
+// Command orphans reports the placeholder users made for listings whose
+// owner had no user row, and the listings they own, as JSON. With -delete
+// it deletes them like deleted accounts.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/accounts"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/config"
+)
+
+func main() {
+	del := flag.Bool("delete", false, "delete the placeholder users and their listings instead of only reporting them")
+	flag.Parse()
+
+	// A missing .env is fine here; the environment may already be set
+	_ = godotenv.Load()
+	// Logs go to stderr so stdout is only the report
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	config.InitDB()
+
+	report, err := accounts.Orphans(config.DB, *del)
+	if err != nil {
+		config.Fatal("Orphan report failed", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		config.Fatal("Failed to write report", err)
+	}
+}
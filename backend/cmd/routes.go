@@ -0,0 +1,128 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/config"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/controllers"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/middleware"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/storage"
+)
+
+// Registers the API's routes; limits keeps the per-route rate limits' counts.
+// Every route here must be in the OpenAPI spec (see routes_test.go).
+func registerRoutes(r *gin.Engine, limits middleware.RateLimitStore) {
+	createLimit := middleware.RateLimit(limits, middleware.PolicyFromEnv("create", "20/10m"))
+	uploadLimit := middleware.RateLimit(limits, middleware.PolicyFromEnv("upload", "10/10m"))
+
+	// Retried creates replay the stored response instead of posting twice.
+	// Runs before createLimit so replays do not use up the caller's quota.
+	idempotencyWindow := middleware.IdempotencyWindowFromEnv(24 * time.Hour)
+	idempotent := middleware.Idempotency(middleware.NewGormIdempotencyStore(config.DB, time.Hour), idempotencyWindow)
+
+	r.GET("/", func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"message": "server is running",
+		})
+	})
+
+	// Writes need a signed-in user, acting as themselves, with a verified campus email
+	verified := middleware.RequireVerified(config.DB)
+
+	r.GET("/users/me", middleware.RequireUser, controllers.GetMe)
+	r.PATCH("/users/me", middleware.RequireUser, controllers.UpdateMe)
+	r.GET("/users/me/activity", middleware.RequireUser, controllers.GetMyActivity)
+	r.GET("/users/me/export", middleware.RequireUser, createLimit, controllers.ExportMyData)
+	r.POST("/users/me/deletion", middleware.RequireUser, controllers.RequestAccountDeletion)
+	r.DELETE("/users/me/deletion", middleware.RequireUser, controllers.CancelAccountDeletion)
+	r.GET("/users/me/storage", middleware.RequireUser, controllers.GetMyStorage)
+	r.GET("/users/me/interactions", middleware.RequireUser, controllers.GetMyInteractions)
+	r.GET("/users/me/blocks", middleware.RequireUser, controllers.GetMyBlocks)
+	r.POST("/users/me/verification", middleware.RequireUser, createLimit, controllers.SendVerificationEmail)
+	r.POST("/users/me/verification/confirm", middleware.RequireUser, controllers.ConfirmEmail)
+	r.GET("/users/:id", controllers.GetUserByID)
+	r.GET("/users/:id/reviews", controllers.GetUserReviews)
+	r.PUT("/users/:id/block", middleware.RequireUser, controllers.PutBlock)
+	r.DELETE("/users/:id/block", middleware.RequireUser, controllers.DeleteBlock)
+	r.POST("/users", middleware.RequireUser, idempotent, createLimit, controllers.CreateUser)
+	r.PUT("/users/:id", middleware.RequireUser, controllers.UpdateUser)
+	r.PATCH("/users/:id", middleware.RequireUser, controllers.UpdateUser)
+
+	// Lists that profiles pick hostels and programmes from
+	admin := middleware.RequireAdmin(config.DB)
+	r.GET("/hostels", controllers.GetHostels)
+	r.PUT("/hostels/:code", admin, controllers.PutHostel)
+	r.DELETE("/hostels/:code", admin, controllers.DeleteHostel)
+	r.GET("/programmes", controllers.GetProgrammes)
+	r.PUT("/programmes/:code", admin, controllers.PutProgramme)
+	r.DELETE("/programmes/:code", admin, controllers.DeleteProgramme)
+
+	// Admins check the genders users attest to, which female-only cabs rely on
+	r.GET("/gender-reviews", admin, controllers.GetPendingGenderReviews)
+	r.PUT("/users/:id/gender-review", admin, controllers.PutGenderReview)
+	r.DELETE("/users/:id/gender-review", admin, controllers.DeleteGenderReview)
+
+	r.POST("/lostfound", verified, idempotent, createLimit, controllers.CreateLostFound)
+	r.GET("/lostfound", controllers.GetLostFound)
+	r.GET("/lostfound/:id", controllers.GetLostFoundByID)
+	r.PUT("/lostfound/:id", verified, controllers.UpdateLostFound)
+	r.PATCH("/lostfound/:id", verified, controllers.UpdateLostFound)
+	r.DELETE("/lostfound/:id", verified, controllers.DeleteLostFound)
+	r.POST("/lostfound/:id/images", verified, controllers.AttachImage(models.ListingLostFound))
+	r.PUT("/lostfound/:id/images", verified, controllers.ReorderImages(models.ListingLostFound))
+	r.DELETE("/lostfound/:id/images/:asset_id", verified, controllers.DetachImage(models.ListingLostFound))
+	r.GET("/lostfound/:id/duplicates", controllers.GetLostFoundSimilar(false))
+	r.GET("/lostfound/:id/matches", controllers.GetLostFoundSimilar(true))
+
+	r.POST("/marketplace", verified, idempotent, createLimit, controllers.CreateMarketplaceItem)
+	r.GET("/marketplace", controllers.GetMarketplaceItems)
+	r.GET("/marketplace/:id", controllers.GetMarketplaceItemByID)
+	r.PUT("/marketplace/:id", verified, controllers.UpdateMarketplaceItem)
+	r.PATCH("/marketplace/:id", verified, controllers.UpdateMarketplaceItem)
+	r.DELETE("/marketplace/:id", verified, controllers.DeleteMarketplaceItem)
+	r.POST("/marketplace/:id/images", verified, controllers.AttachImage(models.ListingMarketplace))
+	r.PUT("/marketplace/:id/images", verified, controllers.ReorderImages(models.ListingMarketplace))
+	r.DELETE("/marketplace/:id/images/:asset_id", verified, controllers.DetachImage(models.ListingMarketplace))
+	r.GET("/marketplace/:id/duplicates", controllers.GetMarketplaceDuplicates)
+	r.POST("/marketplace/:id/interactions", verified, controllers.RecordInteraction(models.ListingMarketplace))
+
+	r.POST("/delibuddy", verified, idempotent, createLimit, controllers.CreateDelibuddy)
+	r.GET("/delibuddy", controllers.GetDelibuddy)
+	r.GET("/delibuddy/:id", controllers.GetDelibuddyByID)
+	r.PUT("/delibuddy/:id", verified, controllers.UpdateDelibuddy)
+	r.PATCH("/delibuddy/:id", verified, controllers.UpdateDelibuddy)
+	r.DELETE("/delibuddy/:id", verified, controllers.DeleteDelibuddy)
+	r.POST("/delibuddy/:id/images", verified, controllers.AttachImage(models.ListingDelibuddy))
+	r.PUT("/delibuddy/:id/images", verified, controllers.ReorderImages(models.ListingDelibuddy))
+	r.DELETE("/delibuddy/:id/images/:asset_id", verified, controllers.DetachImage(models.ListingDelibuddy))
+	r.POST("/delibuddy/:id/interactions", verified, controllers.RecordInteraction(models.ListingDelibuddy))
+
+	r.POST("/cab", verified, idempotent, createLimit, controllers.CreateCab)
+	r.GET("/cab", controllers.GetCabs)
+	r.GET("/cab/:id", controllers.GetCabByID)
+	r.PUT("/cab/:id", verified, controllers.UpdateCab)
+	r.PATCH("/cab/:id", verified, controllers.UpdateCab)
+	r.DELETE("/cab/:id", verified, controllers.DeleteCab)
+	r.POST("/cab/:id/join", verified, controllers.JoinCab)
+	r.DELETE("/cab/:id/join", middleware.RequireUser, controllers.LeaveCab)
+	r.POST("/cab/:id/images", verified, controllers.AttachImage(models.ListingCab))
+	r.PUT("/cab/:id/images", verified, controllers.ReorderImages(models.ListingCab))
+	r.DELETE("/cab/:id/images/:asset_id", verified, controllers.DetachImage(models.ListingCab))
+	r.POST("/cab/:id/interactions", verified, controllers.RecordInteraction(models.ListingCab))
+
+	// The partner accepts (or declines) what the owner recorded; then each
+	// side may review the other once
+	r.POST("/interactions/:id/accept", verified, controllers.AcceptInteraction)
+	r.DELETE("/interactions/:id", middleware.RequireUser, controllers.DeleteInteraction)
+	r.POST("/interactions/:id/reviews", verified, controllers.CreateReview)
+
+	// Called by Clerk (via Svix); authenticated by its signature, not a session
+	r.POST("/webhooks/clerk", controllers.ClerkWebhook)
+
+	r.POST("/upload", verified, uploadLimit, controllers.UploadImage)
+	if local, ok := config.Storage.(*storage.Local); ok {
+		r.Static(local.URLPath, local.Dir)
+	}
+}
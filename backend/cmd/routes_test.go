@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/config"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/middleware"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/openapi"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/storage"
+)
+
+// main refuses to start with an undocumented route; this catches it first
+func TestRoutesAreDocumented(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	spec, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+
+	// With local storage the uploads directory is served too
+	prev := config.Storage
+	config.Storage = storage.NewLocal(t.TempDir(), "/uploads", "http://localhost:8080/uploads")
+	t.Cleanup(func() { config.Storage = prev })
+
+	r := gin.New()
+	openapi.Register(r, spec)
+	registerRoutes(r, middleware.NewMemoryStore(time.Minute))
+
+	if missing := openapi.MissingRoutes(spec, r.Routes()); len(missing) > 0 {
+		t.Errorf("routes missing from the OpenAPI spec: %v", missing)
+	}
+}
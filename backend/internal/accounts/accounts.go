@@ -0,0 +1,194 @@
+// Package accounts deletes user accounts and everything they own: right away
+// when Clerk reports a user deleted, or once the grace period of a deletion
+// the user asked for has passed.
+package accounts
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Each listing type's model, table and owner column
+var listings = []struct {
+	listingType string
+	model       any
+	table       string
+	ownerColumn string
+}{
+	{models.ListingLostFound, &models.LostFound{}, "lost_founds", "owner_id"},
+	{models.ListingMarketplace, &models.MarketplaceItem{}, "marketplace_items", "owner_id"},
+	{models.ListingDelibuddy, &models.Delibuddy{}, "delibuddies", "user_id"},
+	{models.ListingCab, &models.Cab{}, "cabs", "user_id"},
+}
+
+// Purge deletes the user and their data in tx. The user's lost & found
+// reports, marketplace items, delibuddy entries and cab posts are deleted,
+// their images detached for the asset collector. Seats they took in other
+// cabs are given back. Reviews of them and blocks by or of them are deleted.
+// Rows that only mention them are anonymized: their uploads' uploader_id,
+// gender reviews they made as an admin, reviews they wrote and their side of
+// interactions.
+func Purge(tx *gorm.DB, userID string) error {
+	if err := purgeData(tx, userID); err != nil {
+		return err
+	}
+	return tx.Delete(&models.User{}, "id = ?", userID).Error
+}
+
+func purgeData(tx *gorm.DB, userID string) error {
+	for _, l := range listings {
+		owned := tx.Model(l.model).Select("id").Where(l.ownerColumn+" = ?", userID)
+		attachments := tx.Model(&models.AssetAttachment{}).Where("listing_type = ? AND listing_id IN (?)", l.listingType, owned)
+		err := tx.Model(&models.Asset{}).Where("id IN (?)", attachments.Session(&gorm.Session{}).Select("asset_id")).
+			Updates(map[string]any{"state": models.AssetDetached, "state_since": time.Now()}).Error
+		if err != nil {
+			return err
+		}
+		if err := attachments.Session(&gorm.Session{}).Delete(&models.AssetAttachment{}).Error; err != nil {
+			return err
+		}
+		if l.listingType == models.ListingCab {
+			if err := tx.Where("cab_id IN (?)", owned).Delete(&models.CabPassenger{}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where(l.ownerColumn+" = ?", userID).Delete(l.model).Error; err != nil {
+			return err
+		}
+	}
+
+	joined := tx.Model(&models.CabPassenger{}).Select("cab_id").Where("user_id = ?", userID)
+	err := tx.Model(&models.Cab{}).Where("id IN (?)", joined).Updates(map[string]any{
+		"seats_available": gorm.Expr("seats_available + 1"),
+		"version":         gorm.Expr("version + 1"),
+	}).Error
+	if err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&models.CabPassenger{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&models.EmailVerification{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Asset{}).Where("uploader_id = ?", userID).Update("uploader_id", "").Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.User{}).Where("gender_reviewed_by = ?", userID).Update("gender_reviewed_by", "").Error; err != nil {
+		return err
+	}
+
+	// Reviews they received go; reviews they wrote stay, as do the other
+	// side's interactions, without them
+	if err := tx.Where("reviewee_id = ?", userID).Delete(&models.Review{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Review{}).Where("reviewer_id = ?", userID).Update("reviewer_id", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Interaction{}).Where("owner_id = ?", userID).Update("owner_id", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Interaction{}).Where("partner_id = ?", userID).Update("partner_id", nil).Error; err != nil {
+		return err
+	}
+	return tx.Where("blocker_id = ? OR blocked_id = ?", userID, userID).Delete(&models.Block{}).Error
+}
+
+// DefaultInterval is how often the Purger looks for accounts past their grace period.
+const DefaultInterval = time.Hour
+
+// IntervalFromEnv reads ACCOUNT_PURGE_INTERVAL (a duration, 0 disables the
+// purger), keeping the default for unset or malformed values.
+func IntervalFromEnv() time.Duration {
+	v := os.Getenv("ACCOUNT_PURGE_INTERVAL")
+	if v == "" {
+		return DefaultInterval
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		slog.Warn("Invalid account purge setting, using default", "env", "ACCOUNT_PURGE_INTERVAL", "value", v)
+		return DefaultInterval
+	}
+	return d
+}
+
+// Purger deletes accounts whose requested deletion is due.
+type Purger struct {
+	db       *gorm.DB
+	interval time.Duration
+}
+
+func New(db *gorm.DB, interval time.Duration) *Purger {
+	return &Purger{db: db, interval: interval}
+}
+
+// Start runs the purger every interval in the background until ctx is
+// cancelled. It does nothing when the interval is 0.
+func (p *Purger) Start(ctx context.Context) {
+	if p.interval <= 0 {
+		slog.Info("Account purger disabled")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := p.Run(ctx); err != nil {
+					slog.Error("Account purge failed", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Run deletes every account that is due, each in its own transaction, and
+// returns how many were deleted.
+func (p *Purger) Run(ctx context.Context) (int, error) {
+	now := time.Now()
+	var due []string
+	err := p.db.WithContext(ctx).Model(&models.User{}).
+		Where("delete_after IS NOT NULL AND delete_after <= ?", now).Pluck("id", &due).Error
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, id := range due {
+		purged := false
+		err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			// Claiming the user row first, only while still due, loses no race
+			// with the user cancelling; the row itself goes last, as the
+			// listings reference it
+			res := tx.Model(&models.User{}).
+				Where("id = ? AND delete_after IS NOT NULL AND delete_after <= ?", id, now).
+				Update("delete_after", gorm.Expr("delete_after"))
+			if res.Error != nil || res.RowsAffected == 0 {
+				return res.Error
+			}
+			purged = true
+			return Purge(tx, id)
+		})
+		if err != nil {
+			slog.Error("Failed to delete account", "user_id", id, "error", err)
+			continue
+		}
+		if purged {
+			deleted++
+			slog.Info("Deleted account", "user_id", id)
+		}
+	}
+	if len(due) > 0 {
+		slog.Info("Account purge finished", "due", len(due), "deleted", deleted)
+	}
+	return deleted, nil
+}
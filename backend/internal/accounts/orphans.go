@@ -0,0 +1,116 @@
+package accounts
+
+import (
+	"strings"
+
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// OrphanEmailDomain marks placeholder users made for listings whose owner
+// had no user row. Clerk's copy replaces the placeholder once the user next
+// syncs; placeholders that never do belong to accounts that are gone.
+const OrphanEmailDomain = "orphaned.invalid"
+
+// AdoptOrphans gives every listing owner without a user row a placeholder
+// user, so the listings' foreign keys to users can be added. Delibuddy
+// entries and cab posts used to copy the poster's username; the placeholder
+// takes it when it is free. It returns how many placeholders were made.
+func AdoptOrphans(db *gorm.DB) (int, error) {
+	adopted := 0
+	for _, l := range listings {
+		if !db.Migrator().HasTable(l.table) {
+			continue
+		}
+		owner := "l." + l.ownerColumn
+		columns := owner + " AS id"
+		if db.Migrator().HasColumn(l.table, "username") {
+			columns += ", MAX(l.username) AS username"
+		}
+
+		var orphans []struct {
+			ID       string
+			Username string
+		}
+		err := db.Table(l.table + " AS l").Select(columns).
+			Joins("LEFT JOIN users u ON u.id = " + owner).
+			Where("u.id IS NULL").Group(owner).Scan(&orphans).Error
+		if err != nil {
+			return adopted, err
+		}
+
+		for _, o := range orphans {
+			username := o.Username
+			var taken int64
+			if err := db.Model(&models.User{}).Where("username = ?", username).Count(&taken).Error; err != nil {
+				return adopted, err
+			}
+			if username == "" || taken > 0 {
+				username = o.ID
+			}
+			user := models.User{ID: o.ID, Email: o.ID + "@" + OrphanEmailDomain, Username: username}
+			if err := db.Create(&user).Error; err != nil {
+				return adopted, err
+			}
+			adopted++
+		}
+	}
+	return adopted, nil
+}
+
+// OrphanedListing is a listing owned by a placeholder user.
+type OrphanedListing struct {
+	ListingType string `json:"listing_type"`
+	ListingID   uint   `json:"listing_id"`
+	OwnerID     string `json:"owner_id"`
+}
+
+// OrphanReport lists the placeholder users and the listings they own.
+type OrphanReport struct {
+	Placeholders []string          `json:"placeholders"`
+	Listings     []OrphanedListing `json:"listings"`
+	Deleted      int               `json:"deleted"` // placeholders deleted with their listings
+}
+
+// Orphans reports the placeholder users still left and their listings. With
+// del it also deletes them, as Purge does for a deleted account.
+func Orphans(db *gorm.DB, del bool) (*OrphanReport, error) {
+	report := &OrphanReport{Placeholders: []string{}, Listings: []OrphanedListing{}}
+	err := db.Model(&models.User{}).Where("email LIKE ?", "%@"+OrphanEmailDomain).
+		Order("id").Pluck("id", &report.Placeholders).Error
+	if err != nil || len(report.Placeholders) == 0 {
+		return report, err
+	}
+
+	for _, l := range listings {
+		var rows []OrphanedListing
+		err := db.Model(l.model).Select("? AS listing_type, id AS listing_id, "+l.ownerColumn+" AS owner_id", l.listingType).
+			Where(l.ownerColumn+" IN ?", report.Placeholders).Order("id").Scan(&rows).Error
+		if err != nil {
+			return report, err
+		}
+		report.Listings = append(report.Listings, rows...)
+	}
+
+	if !del {
+		return report, nil
+	}
+	for _, id := range report.Placeholders {
+		// Only while still a placeholder: a Clerk sync may have claimed it since
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var email []string
+			if err := tx.Model(&models.User{}).Where("id = ?", id).Pluck("email", &email).Error; err != nil {
+				return err
+			}
+			if len(email) == 0 || !strings.HasSuffix(email[0], "@"+OrphanEmailDomain) {
+				return nil
+			}
+			report.Deleted++
+			return Purge(tx, id)
+		})
+		if err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,107 @@
+package apierror
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Code is a stable, machine-readable error identifier. Clients should switch
+// on these instead of matching messages, which may change.
+type Code string
+
+const (
+	CodeInvalidJSON      Code = "invalid_json"
+	CodeValidationFailed Code = "validation_failed"
+	CodeNotFound         Code = "not_found"
+	CodeRouteNotFound    Code = "route_not_found"
+	CodeMethodNotAllowed Code = "method_not_allowed"
+	CodeUnauthorized     Code = "unauthorized"
+	CodeForbidden        Code = "forbidden"
+	CodeUnverified       Code = "email_not_verified"
+	CodeConflict         Code = "conflict"
+	CodePrecondition     Code = "precondition_failed"
+	CodeIdempotencyReuse Code = "idempotency_key_reused"
+	CodePayloadTooLarge  Code = "payload_too_large"
+	CodeUnsupportedMedia Code = "unsupported_media_type"
+	CodeRateLimited      Code = "rate_limited"
+	CodeUploadQuota      Code = "upload_quota_exceeded"
+	CodeStorageQuota     Code = "storage_quota_exceeded"
+	CodeInternal         Code = "internal_error"
+)
+
+// FieldError describes one invalid field of a request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is the body of every failed response: {"error": {...}}.
+type Error struct {
+	Status    int          `json:"-"`
+	Code      Code         `json:"code"`
+	Message   string       `json:"message"`
+	Fields    []FieldError `json:"fields,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return string(e.Code) + ": " + e.Message
+}
+
+func New(status int, code Code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func InvalidJSON(message string) *Error {
+	return New(http.StatusBadRequest, CodeInvalidJSON, message)
+}
+
+// Validation reports one or more invalid fields.
+func Validation(message string, fields ...FieldError) *Error {
+	e := New(http.StatusBadRequest, CodeValidationFailed, message)
+	e.Fields = fields
+	return e
+}
+
+// Field is shorthand for a validation error on a single field.
+func Field(field, message string) *Error {
+	return Validation(message, FieldError{Field: field, Message: message})
+}
+
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, message)
+}
+
+// Unauthorized means the request has no valid credentials.
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, CodeForbidden, message)
+}
+
+func Conflict(message string, fields ...FieldError) *Error {
+	e := New(http.StatusConflict, CodeConflict, message)
+	e.Fields = fields
+	return e
+}
+
+// PreconditionFailed reports a stale If-Match or a concurrent modification.
+func PreconditionFailed(message string) *Error {
+	return New(http.StatusPreconditionFailed, CodePrecondition, message)
+}
+
+func Internal(message string) *Error {
+	return New(http.StatusInternalServerError, CodeInternal, message)
+}
+
+// Abort writes the error envelope and stops the handler chain.
+// The request ID is taken from the response header set by the RequestID middleware.
+func Abort(c *gin.Context, e *Error) {
+	if e.RequestID == "" {
+		e.RequestID = c.Writer.Header().Get("X-Request-ID")
+	}
+	c.AbortWithStatusJSON(e.Status, gin.H{"error": e})
+}
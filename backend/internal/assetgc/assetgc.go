@@ -0,0 +1,270 @@
+// Package assetgc deletes uploads that no listing uses: images that were
+// uploaded but never attached, and images that were detached or whose
+// listing was deleted.
+package assetgc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/storage"
+	"gorm.io/gorm"
+)
+
+// Options control a collection run.
+type Options struct {
+	// Grace is how long an asset must have been unreferenced before it is
+	// deleted, so a client has time to attach what it just uploaded. The
+	// daily upload quota only counts stored uploads, so a grace under 24h
+	// lets users upload more than it allows.
+	Grace time.Duration
+	// Interval between runs of the periodic collector; 0 disables it.
+	Interval time.Duration
+	// DryRun reports what would be deleted without deleting anything.
+	DryRun bool
+	// BatchSize caps the assets deleted per run.
+	BatchSize int
+}
+
+var DefaultOptions = Options{
+	Grace:     24 * time.Hour,
+	Interval:  time.Hour,
+	BatchSize: 500,
+}
+
+// OptionsFromEnv reads ASSET_GC_GRACE and ASSET_GC_INTERVAL (durations,
+// ASSET_GC_INTERVAL=0 disables the collector), ASSET_GC_DRY_RUN and
+// ASSET_GC_BATCH_SIZE, keeping the default for unset or malformed values.
+func OptionsFromEnv() Options {
+	o := DefaultOptions
+	o.Grace = envDuration("ASSET_GC_GRACE", o.Grace)
+	o.Interval = envDuration("ASSET_GC_INTERVAL", o.Interval)
+	if v := os.Getenv("ASSET_GC_DRY_RUN"); v != "" {
+		dry, err := strconv.ParseBool(v)
+		if err != nil {
+			slog.Warn("Invalid asset collector setting, using default", "env", "ASSET_GC_DRY_RUN", "value", v)
+		} else {
+			o.DryRun = dry
+		}
+	}
+	if v := os.Getenv("ASSET_GC_BATCH_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			slog.Warn("Invalid asset collector setting, using default", "env", "ASSET_GC_BATCH_SIZE", "value", v)
+		} else {
+			o.BatchSize = n
+		}
+	}
+	return o
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		slog.Warn("Invalid asset collector setting, using default", "env", key, "value", v)
+		return def
+	}
+	return d
+}
+
+// Candidate is an unreferenced asset past its grace period.
+type Candidate struct {
+	AssetID    uint      `json:"asset_id"`
+	UploaderID string    `json:"uploader_id"`
+	State      string    `json:"state"`
+	StateSince time.Time `json:"state_since"`
+	Keys       []string  `json:"keys"`
+	Bytes      int64     `json:"bytes"`
+}
+
+// Report describes one run. In a dry run Deleted and FreedBytes stay zero.
+type Report struct {
+	DryRun     bool        `json:"dry_run"`
+	Cutoff     time.Time   `json:"cutoff"`
+	Candidates []Candidate `json:"candidates"`
+	Deleted    int         `json:"deleted"`
+	FreedBytes int64       `json:"freed_bytes"`
+	Failed     int         `json:"failed"`
+}
+
+// Collector finds and deletes orphaned assets.
+type Collector struct {
+	db    *gorm.DB
+	store storage.Store
+	opts  Options
+}
+
+func New(db *gorm.DB, store storage.Store, opts Options) *Collector {
+	return &Collector{db: db, store: store, opts: opts}
+}
+
+// Start runs the collector every Interval in the background until ctx is
+// cancelled. It does nothing when Interval is 0.
+func (g *Collector) Start(ctx context.Context) {
+	if g.opts.Interval <= 0 {
+		slog.Info("Asset collector disabled")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(g.opts.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := g.Run(ctx); err != nil {
+					slog.Error("Asset collection failed", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Run performs one collection and logs a summary.
+func (g *Collector) Run(ctx context.Context) (*Report, error) {
+	report := &Report{DryRun: g.opts.DryRun, Cutoff: time.Now().Add(-g.opts.Grace), Candidates: []Candidate{}}
+
+	assets, err := g.orphans(ctx, report.Cutoff)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range assets {
+		cand := candidate(a)
+		report.Candidates = append(report.Candidates, cand)
+		if g.opts.DryRun {
+			slog.Info("Orphaned asset (dry run)", "asset_id", cand.AssetID, "state", cand.State,
+				"state_since", cand.StateSince, "bytes", cand.Bytes)
+			continue
+		}
+		if err := g.delete(ctx, a, report.Cutoff, cand.Keys); err != nil {
+			if errors.Is(err, errReferenced) {
+				continue
+			}
+			report.Failed++
+			slog.Error("Failed to delete orphaned asset", "asset_id", a.ID, "error", err)
+			continue
+		}
+		report.Deleted++
+		report.FreedBytes += cand.Bytes
+	}
+
+	slog.Info("Asset collection finished", "dry_run", report.DryRun, "candidates", len(report.Candidates),
+		"deleted", report.Deleted, "freed_bytes", report.FreedBytes, "failed", report.Failed)
+	return report, nil
+}
+
+var errReferenced = errors.New("asset was referenced again")
+
+// Unreferenced assets whose state has not changed since cutoff. Besides the
+// attachment table, the legacy cover columns and any stored URL (avatars,
+// image_url values from before images were attached) count as references.
+func (g *Collector) orphans(ctx context.Context, cutoff time.Time) ([]models.Asset, error) {
+	// URLs live in the variants' JSON, so they are checked here. Paging on
+	// the id keeps assets that are still in use through a URL from filling
+	// every batch and hiding the orphans behind them.
+	out := []models.Asset{}
+	var lastID uint
+	for len(out) < g.opts.BatchSize {
+		var assets []models.Asset
+		err := unreferenced(g.db.WithContext(ctx), cutoff).Where("id > ?", lastID).
+			Order("id").Limit(g.opts.BatchSize).Find(&assets).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range assets {
+			lastID = a.ID
+			used, err := urlReferenced(g.db.WithContext(ctx), a)
+			if err != nil {
+				return nil, err
+			}
+			if !used {
+				out = append(out, a)
+				if len(out) == g.opts.BatchSize {
+					break
+				}
+			}
+		}
+		if len(assets) < g.opts.BatchSize {
+			break
+		}
+	}
+	return out, nil
+}
+
+func unreferenced(q *gorm.DB, cutoff time.Time) *gorm.DB {
+	return q.Model(&models.Asset{}).
+		Where("state <> ? AND state_since < ?", models.AssetAttached, cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM asset_attachments WHERE asset_attachments.asset_id = assets.id)").
+		Where("NOT EXISTS (SELECT 1 FROM lost_founds WHERE lost_founds.image_asset_id = assets.id)").
+		Where("NOT EXISTS (SELECT 1 FROM marketplace_items WHERE marketplace_items.image_asset_id = assets.id)")
+}
+
+func urlReferenced(db *gorm.DB, a models.Asset) (bool, error) {
+	urls := make([]string, 0, len(a.Variants))
+	for _, v := range a.Variants {
+		urls = append(urls, v.URL)
+	}
+	for _, q := range []struct {
+		model  any
+		column string
+	}{
+		{&models.User{}, "avatar_url"},
+		{&models.LostFound{}, "image_url"},
+		{&models.MarketplaceItem{}, "image_url"},
+	} {
+		var n int64
+		if err := db.Model(q.model).Where(q.column+" IN ?", urls).Count(&n).Error; err != nil {
+			return false, err
+		}
+		if n > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Removes the row first, re-checking it is still unreferenced, so a listing
+// can never point at a deleted file. Storage left behind by a failed delete
+// is only logged.
+func (g *Collector) delete(ctx context.Context, a models.Asset, cutoff time.Time, keys []string) error {
+	res := unreferenced(g.db.WithContext(ctx), cutoff).Where("id = ?", a.ID).Delete(&models.Asset{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errReferenced
+	}
+
+	for _, key := range keys {
+		if err := g.store.Delete(ctx, key); err != nil {
+			slog.Warn("Failed to delete stored variant", "asset_id", a.ID, "key", key, "error", err)
+		}
+	}
+	return nil
+}
+
+// Variants can share a stored object, so keys are de-duplicated
+func candidate(a models.Asset) Candidate {
+	c := Candidate{AssetID: a.ID, UploaderID: a.UploaderID, State: a.State, StateSince: a.StateSince,
+		Keys: []string{}, Bytes: a.StoredBytes()}
+	seen := map[string]bool{}
+	for _, v := range a.Variants {
+		if !seen[v.Key] {
+			seen[v.Key] = true
+			c.Keys = append(c.Keys, v.Key)
+		}
+	}
+	sort.Strings(c.Keys)
+	return c
+}
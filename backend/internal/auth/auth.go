@@ -0,0 +1,234 @@
+// Package auth verifies the bearer tokens sent by the frontend and resolves
+// them to a Clerk user id.
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidToken is returned for malformed, expired or wrongly signed tokens.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Verifier turns a bearer token into the id of the user it was issued to.
+type Verifier interface {
+	Verify(ctx context.Context, token string) (userID string, err error)
+}
+
+// How far clocks may drift between Clerk and this server
+const leeway = 5 * time.Second
+
+// Unknown key ids trigger a JWKS refresh at most this often
+const minRefresh = time.Minute
+
+// JWKS verifies Clerk session tokens (RS256 JWTs) against the instance's
+// published signing keys.
+type JWKS struct {
+	URL    string
+	Issuer string
+	// AuthorizedParties, when set, limits the azp claim to these origins.
+	AuthorizedParties []string
+	Client            *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// NewJWKS verifies tokens issued by issuer, e.g.
+// "https://clerk.example.com". jwksURL defaults to the issuer's
+// /.well-known/jwks.json.
+func NewJWKS(issuer, jwksURL string, authorizedParties []string) *JWKS {
+	issuer = strings.TrimSuffix(issuer, "/")
+	if jwksURL == "" {
+		jwksURL = issuer + "/.well-known/jwks.json"
+	}
+	return &JWKS{
+		URL:               jwksURL,
+		Issuer:            issuer,
+		AuthorizedParties: authorizedParties,
+		Client:            &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type claims struct {
+	Subject   string `json:"sub"`
+	Issuer    string `json:"iss"`
+	Party     string `json:"azp"`
+	ExpiresAt int64  `json:"exp"`
+	NotBefore int64  `json:"nbf"`
+}
+
+func (v *JWKS) Verify(ctx context.Context, token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("%w: not a JWT", ErrInvalidToken)
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return "", err
+	}
+	if h.Alg != "RS256" {
+		return "", fmt.Errorf("%w: unsupported alg %q", ErrInvalidToken, h.Alg)
+	}
+
+	key, err := v.key(ctx, h.Kid)
+	if err != nil {
+		return "", err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("%w: bad signature encoding", ErrInvalidToken)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+		return "", fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	switch {
+	case c.Subject == "":
+		return "", fmt.Errorf("%w: missing sub", ErrInvalidToken)
+	case c.Issuer != v.Issuer:
+		return "", fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, c.Issuer)
+	case c.ExpiresAt == 0 || now.After(time.Unix(c.ExpiresAt, 0).Add(leeway)):
+		return "", fmt.Errorf("%w: expired", ErrInvalidToken)
+	case c.NotBefore != 0 && now.Add(leeway).Before(time.Unix(c.NotBefore, 0)):
+		return "", fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+	case c.Party != "" && len(v.AuthorizedParties) > 0 && !contains(v.AuthorizedParties, c.Party):
+		return "", fmt.Errorf("%w: unauthorized party %q", ErrInvalidToken, c.Party)
+	}
+	return c.Subject, nil
+}
+
+func decodeSegment(seg string, out any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return fmt.Errorf("%w: bad encoding", ErrInvalidToken)
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("%w: bad JSON", ErrInvalidToken)
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Looks up a signing key, refetching the set when Clerk has rotated keys
+func (v *JWKS) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(v.fetchedAt) < minRefresh {
+		return nil, fmt.Errorf("%w: unknown key %q", ErrInvalidToken, kid)
+	}
+
+	keys, err := v.fetch(ctx)
+	v.fetchedAt = time.Now()
+	if err != nil {
+		return nil, fmt.Errorf("fetch JWKS: %w", err)
+	}
+	v.keys = keys
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: unknown key %q", ErrInvalidToken, kid)
+}
+
+// Check fetches the key set once so a wrong URL is caught at startup.
+func (v *JWKS) Check(ctx context.Context) error {
+	keys, err := v.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	v.mu.Lock()
+	v.keys, v.fetchedAt = keys, time.Now()
+	v.mu.Unlock()
+	return nil
+}
+
+func (v *JWKS) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", v.URL, resp.Status)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no RSA keys in JWKS")
+	}
+	return keys, nil
+}
+
+// Insecure trusts the bearer token as the user id. It is only for local
+// development without a Clerk instance.
+type Insecure struct{}
+
+func (Insecure) Verify(_ context.Context, token string) (string, error) {
+	if token == "" {
+		return "", ErrInvalidToken
+	}
+	return token, nil
+}
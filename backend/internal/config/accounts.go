@@ -0,0 +1,22 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"time"
+)
+
+// AccountDeletionGrace is how long a requested account deletion waits, during
+// which the user can cancel it (ACCOUNT_DELETION_GRACE, e.g. "168h").
+var AccountDeletionGrace = 7 * 24 * time.Hour
+
+func InitAccounts() {
+	if v := os.Getenv("ACCOUNT_DELETION_GRACE"); v != "" {
+		grace, err := time.ParseDuration(v)
+		if err != nil || grace < 0 {
+			slog.Warn("Invalid account deletion grace, using default", "env", "ACCOUNT_DELETION_GRACE", "value", v)
+		} else {
+			AccountDeletionGrace = grace
+		}
+	}
+}
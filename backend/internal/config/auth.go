@@ -0,0 +1,65 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shreyashsri79/vitbuddy-backend/internal/auth"
+)
+
+// Auth verifies bearer tokens; nil when authentication is not configured, in
+// which case routes that need a signed-in user answer 401.
+var Auth auth.Verifier
+
+// FemaleOnlyNeedsReview limits female-only cabs to users whose gender an admin
+// has verified; otherwise the user's own attestation is enough.
+var FemaleOnlyNeedsReview bool
+
+// InitAuth verifies Clerk session tokens issued by CLERK_ISSUER (keys from
+// CLERK_JWKS_URL, by default the issuer's /.well-known/jwks.json), optionally
+// restricted to the origins in CLERK_AUTHORIZED_PARTIES. AUTH_INSECURE=true
+// instead accepts the user id itself as the token, for local development.
+// FEMALE_ONLY_NEEDS_REVIEW=true sets FemaleOnlyNeedsReview.
+func InitAuth() {
+	if v := os.Getenv("FEMALE_ONLY_NEEDS_REVIEW"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			Fatal("Invalid FEMALE_ONLY_NEEDS_REVIEW", err)
+		}
+		FemaleOnlyNeedsReview = b
+	}
+
+	if os.Getenv("AUTH_INSECURE") == "true" {
+		slog.Warn("AUTH_INSECURE is set: bearer tokens are trusted as user ids, never use this in production")
+		Auth = auth.Insecure{}
+		return
+	}
+
+	issuer := os.Getenv("CLERK_ISSUER")
+	if issuer == "" {
+		slog.Warn("CLERK_ISSUER is not set, authenticated routes will reject every request")
+		return
+	}
+
+	var parties []string
+	for _, p := range strings.Split(os.Getenv("CLERK_AUTHORIZED_PARTIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			parties = append(parties, p)
+		}
+	}
+	verifier := auth.NewJWKS(issuer, os.Getenv("CLERK_JWKS_URL"), parties)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := verifier.Check(ctx); err != nil {
+		Fatal("Failed to load Clerk signing keys", errors.Join(errors.New(verifier.URL), err))
+	}
+
+	Auth = verifier
+	slog.Info("Authentication initialized", "issuer", issuer)
+}
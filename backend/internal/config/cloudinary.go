@@ -1,23 +0,0 @@
-package config
-
-import (
-	"log"
-	"os"
-
-	"github.com/cloudinary/cloudinary-go/v2"
-)
-
-var CLD *cloudinary.Cloudinary
-
-func InitCloudinary() {
-	cld, err := cloudinary.NewFromParams(
-		os.Getenv("CLOUDINARY_CLOUD_NAME"),
-		os.Getenv("CLOUDINARY_API_KEY"),
-		os.Getenv("CLOUDINARY_API_SECRET"),
-	)
-	if err != nil {
-		log.Fatal("❌ Failed to initialize Cloudinary:", err)
-	}
-	CLD = cld
-	log.Println("✅ Cloudinary initialized!")
-}
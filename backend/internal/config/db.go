@@ -2,9 +2,10 @@ package config
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 
+	"github.com/shreyashsri79/vitbuddy-backend/internal/accounts"
 	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -25,21 +26,128 @@ func InitDB() {
 
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
 	if err != nil {
-		log.Fatal("❌ Failed to connect to database:", err)
+		Fatal("Failed to connect to database", err)
+	}
+
+	if err := registerGormTracing(db); err != nil {
+		Fatal("Failed to register database tracing", err)
 	}
 
 	DB = db
 
+	// Listings reference their owner; owners without a user row get a
+	// placeholder before the foreign keys are added
+	if err := db.AutoMigrate(&models.User{}); err != nil {
+		Fatal("Failed to migrate database", err)
+	}
+	adopted, err := accounts.AdoptOrphans(db)
+	if err != nil {
+		Fatal("Failed to adopt orphaned listings", err)
+	}
+	if adopted > 0 {
+		slog.Warn("Created placeholder users for orphaned listings", "count", adopted)
+	}
+
+	if err := renumberAttachments(db); err != nil {
+		Fatal("Failed to migrate database", err)
+	}
+
 	err = db.AutoMigrate(
 		&models.User{},
+		&models.Asset{},
+		&models.AssetAttachment{},
 		&models.LostFound{},
 		&models.MarketplaceItem{},
 		&models.Delibuddy{},
 		&models.Cab{},
+		&models.CabPassenger{},
+		&models.IdempotencyKey{},
+		&models.WebhookEvent{},
+		&models.EmailVerification{},
+		&models.Hostel{},
+		&models.Programme{},
+		&models.Interaction{},
+		&models.Review{},
+		&models.Block{},
 	)
 	if err != nil {
-		log.Fatal("❌ Failed to migrate database:", err)
+		Fatal("Failed to migrate database", err)
+	}
+	if err := dropCopiedUsernames(db); err != nil {
+		Fatal("Failed to migrate database", err)
+	}
+	if err := backfillAssetBytes(db); err != nil {
+		Fatal("Failed to backfill asset sizes", err)
+	}
+	if err := backfillCabSeats(db); err != nil {
+		Fatal("Failed to backfill cab seats", err)
+	}
+
+	slog.Info("Connected to Amazon RDS PostgreSQL")
+}
+
+// Assets uploaded before sizes were recorded count towards storage quotas
+// once their size is filled in from the variants
+func backfillAssetBytes(db *gorm.DB) error {
+	var assets []models.Asset
+	return db.Where("bytes = 0").FindInBatches(&assets, 200, func(tx *gorm.DB, _ int) error {
+		for _, a := range assets {
+			if err := tx.Model(&a).Update("bytes", a.StoredBytes()).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
+
+// Attachments written concurrently could share a position; they are
+// renumbered before positions become unique per listing
+func renumberAttachments(db *gorm.DB) error {
+	m := db.Migrator()
+	if !m.HasTable(&models.AssetAttachment{}) || m.HasIndex(&models.AssetAttachment{}, "idx_attachment_position") {
+		return nil
+	}
+	var rows []models.AssetAttachment
+	err := db.Order("listing_type, listing_id, position, id").Find(&rows).Error
+	if err != nil {
+		return err
 	}
+	next := 0
+	for i, a := range rows {
+		if i == 0 || a.ListingType != rows[i-1].ListingType || a.ListingID != rows[i-1].ListingID {
+			next = 0
+		}
+		if a.Position != next {
+			if err := db.Model(&a).Update("position", next).Error; err != nil {
+				return err
+			}
+		}
+		next++
+	}
+	// Covered by the unique index, which starts with the same columns
+	if m.HasIndex(&models.AssetAttachment{}, "idx_attachment_listing") {
+		return m.DropIndex(&models.AssetAttachment{}, "idx_attachment_listing")
+	}
+	return nil
+}
 
-	log.Println("✅ Connected to Amazon RDS PostgreSQL!")
+// Cabs posted before their size was stored offered their free seats plus
+// the ones passengers took
+func backfillCabSeats(db *gorm.DB) error {
+	return db.Model(&models.Cab{}).Where("seats = 0").Update("seats",
+		gorm.Expr("seats_available + (SELECT COUNT(*) FROM cab_passengers WHERE cab_passengers.cab_id = cabs.id)")).Error
+}
+
+// Delibuddy entries and cab posts used to copy the poster's username; it now
+// comes from their owner
+func dropCopiedUsernames(db *gorm.DB) error {
+	for _, model := range []any{&models.Delibuddy{}, &models.Cab{}} {
+		if !db.Migrator().HasColumn(model, "username") {
+			continue
+		}
+		if err := db.Migrator().DropColumn(model, "username"); err != nil {
+			return err
+		}
+	}
+	return nil
 }
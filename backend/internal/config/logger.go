@@ -0,0 +1,26 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// InitLogger installs a JSON slog logger as the process-wide default.
+// LOG_LEVEL accepts debug, info, warn or error (default info).
+func InitLogger() {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(os.Getenv("LOG_LEVEL")))); err != nil {
+		level = slog.LevelInfo
+	}
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level})).
+		With("service", ServiceName)
+	slog.SetDefault(logger)
+}
+
+// Fatal logs an error and exits, replacing log.Fatal for startup failures.
+func Fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/shreyashsri79/vitbuddy-backend/internal/mailer"
+)
+
+var Mailer mailer.Mailer
+
+// EmailCodeTTL is how long an email verification code stays valid
+// (EMAIL_CODE_TTL, e.g. "10m").
+var EmailCodeTTL = 10 * time.Minute
+
+// InitMailer picks the backend from MAILER: "smtp" (SMTP_HOST, SMTP_PORT,
+// SMTP_USERNAME, SMTP_PASSWORD, SMTP_FROM) or "memory", which sends nothing.
+// Without MAILER, SMTP is used when SMTP_HOST is set.
+func InitMailer() {
+	driver := os.Getenv("MAILER")
+	if driver == "" {
+		driver = "memory"
+		if os.Getenv("SMTP_HOST") != "" {
+			driver = "smtp"
+		}
+	}
+
+	switch driver {
+	case "smtp":
+		port := 587
+		if v := os.Getenv("SMTP_PORT"); v != "" {
+			p, err := strconv.Atoi(v)
+			if err != nil {
+				Fatal("Invalid SMTP_PORT", err)
+			}
+			port = p
+		}
+		from := os.Getenv("SMTP_FROM")
+		if os.Getenv("SMTP_HOST") == "" || from == "" {
+			Fatal("Failed to initialize mailer", errors.New("SMTP_HOST and SMTP_FROM are required"))
+		}
+		Mailer = &mailer.SMTP{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+	case "memory":
+		slog.Warn("Using the in-memory mailer, no email will be delivered")
+		Mailer = &mailer.Memory{}
+	default:
+		Fatal("Failed to initialize mailer", fmt.Errorf("unknown MAILER %q (want smtp or memory)", driver))
+	}
+	if v := os.Getenv("EMAIL_CODE_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil || ttl <= 0 {
+			slog.Warn("Invalid email code TTL, using default", "env", "EMAIL_CODE_TTL", "value", v)
+		} else {
+			EmailCodeTTL = ttl
+		}
+	}
+	slog.Info("Mailer initialized", "driver", driver)
+}
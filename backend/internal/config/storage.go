@@ -0,0 +1,134 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shreyashsri79/vitbuddy-backend/internal/imageproc"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/storage"
+)
+
+// LocalUploadsPath is the route the local driver's files are served on.
+const LocalUploadsPath = "/uploads"
+
+var Storage storage.Store
+
+// UploadLimits bound what /upload accepts (UPLOAD_* variables).
+var UploadLimits = imageproc.DefaultLimits
+
+// UploadQuota caps one user's uploads; 0 means unlimited.
+type UploadQuota struct {
+	Daily int   `json:"daily_uploads"` // uploads in any 24 hours
+	Bytes int64 `json:"bytes"`         // stored bytes across all their assets
+}
+
+// UploadQuotas are the quotas per role, overridden by
+// UPLOAD_QUOTA_<ROLE>="<uploads per day>/<bytes>", e.g. "30/209715200".
+var UploadQuotas = map[string]UploadQuota{
+	models.RoleUser:  {Daily: 30, Bytes: 200 << 20},
+	models.RoleAdmin: {},
+}
+
+// QuotaFor returns the quota of role; unknown roles get the user quota.
+func QuotaFor(role string) UploadQuota {
+	if q, ok := UploadQuotas[role]; ok {
+		return q
+	}
+	return UploadQuotas[models.RoleUser]
+}
+
+// InitStorage picks the image store from STORAGE_DRIVER (cloudinary, local or
+// s3; cloudinary by default) and exits if it cannot be reached.
+func InitStorage() {
+	store, err := newStore(os.Getenv("STORAGE_DRIVER"))
+	if err != nil {
+		Fatal("Failed to initialize storage", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := store.Check(ctx); err != nil {
+		Fatal("Storage backend is not reachable", fmt.Errorf("%s: %w", store.Driver(), err))
+	}
+
+	Storage = store
+	UploadLimits = imageproc.LimitsFromEnv()
+	for role := range UploadQuotas {
+		UploadQuotas[role] = quotaFromEnv(role, UploadQuotas[role])
+	}
+	slog.Info("Storage initialized", "driver", store.Driver(),
+		"max_bytes", UploadLimits.MaxBytes, "allowed_types", UploadLimits.AllowedTypes)
+}
+
+func quotaFromEnv(role string, def UploadQuota) UploadQuota {
+	key := "UPLOAD_QUOTA_" + strings.ToUpper(role)
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	daily, bytes, ok := strings.Cut(v, "/")
+	d, errD := strconv.Atoi(strings.TrimSpace(daily))
+	b, errB := strconv.ParseInt(strings.TrimSpace(bytes), 10, 64)
+	if !ok || errD != nil || errB != nil || d < 0 || b < 0 {
+		slog.Warn("Invalid upload quota, using default", "env", key, "value", v)
+		return def
+	}
+	return UploadQuota{Daily: d, Bytes: b}
+}
+
+func newStore(driver string) (storage.Store, error) {
+	switch driver {
+	case "", "cloudinary":
+		folder := os.Getenv("CLOUDINARY_FOLDER")
+		if folder == "" {
+			folder = "vitbuddy"
+		}
+		return storage.NewCloudinary(
+			os.Getenv("CLOUDINARY_CLOUD_NAME"),
+			os.Getenv("CLOUDINARY_API_KEY"),
+			os.Getenv("CLOUDINARY_API_SECRET"),
+			folder,
+		)
+
+	case "local":
+		dir := os.Getenv("STORAGE_LOCAL_DIR")
+		if dir == "" {
+			dir = "uploads"
+		}
+		baseURL := os.Getenv("STORAGE_LOCAL_BASE_URL")
+		if baseURL == "" {
+			port := os.Getenv("PORT")
+			if port == "" {
+				port = "8080"
+			}
+			baseURL = "http://localhost:" + port + LocalUploadsPath
+		}
+		return storage.NewLocal(dir, LocalUploadsPath, baseURL), nil
+
+	case "s3":
+		useSSL := true
+		if v := os.Getenv("S3_USE_SSL"); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("S3_USE_SSL: %w", err)
+			}
+			useSSL = b
+		}
+		return storage.NewS3(storage.S3Options{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			UseSSL:    useSSL,
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+		})
+	}
+	return nil, fmt.Errorf("unknown STORAGE_DRIVER %q (want cloudinary, local or s3)", driver)
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const ServiceName = "vitbuddy-backend"
+
+var Tracer trace.Tracer = otel.Tracer(ServiceName)
+
+// InitTracing sets up the global tracer provider and W3C propagation.
+// OTEL_TRACES_EXPORTER picks the exporter: "otlp" (default), "stdout" or "none".
+// The OTLP exporter reads OTEL_EXPORTER_OTLP_ENDPOINT, so it can point at a local collector.
+func InitTracing() func(context.Context) error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+	switch os.Getenv("OTEL_TRACES_EXPORTER") {
+	case "none":
+		slog.Warn("Tracing disabled")
+		return func(context.Context) error { return nil }
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		exporter, err = otlptracehttp.New(context.Background())
+	}
+	if err != nil {
+		Fatal("Failed to initialize trace exporter", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName(ServiceName),
+	))
+	if err != nil {
+		Fatal("Failed to build trace resource", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+	Tracer = tp.Tracer(ServiceName)
+
+	slog.Info("Tracing initialized")
+	return tp.Shutdown
+}
+
+const gormSpanKey = "otel:span"
+
+// Registers GORM callbacks that wrap every query in a client span.
+// Spans are parented to the context passed via db.WithContext.
+func registerGormTracing(db *gorm.DB) error {
+	before := func(op string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			ctx, span := Tracer.Start(tx.Statement.Context, "gorm."+op,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					semconv.DBSystemPostgreSQL,
+					attribute.String("db.operation.name", op),
+				),
+			)
+			tx.Statement.Context = ctx
+			tx.InstanceSet(gormSpanKey, span)
+		}
+	}
+	after := func(tx *gorm.DB) {
+		v, ok := tx.InstanceGet(gormSpanKey)
+		if !ok {
+			return
+		}
+		span := v.(trace.Span)
+		defer span.End()
+
+		span.SetAttributes(
+			attribute.String("db.collection.name", tx.Statement.Table),
+			// Parameterized only: bound values are user data (emails, phones, codes)
+			attribute.String("db.query.text", tx.Statement.SQL.String()),
+			attribute.Int64("db.rows_affected", tx.Statement.RowsAffected),
+		)
+		if tx.Error != nil && tx.Error != gorm.ErrRecordNotFound {
+			span.RecordError(tx.Error)
+			span.SetStatus(codes.Error, tx.Error.Error())
+		}
+	}
+
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("otel:before_create", before("create")); err != nil {
+		return err
+	}
+	if err := cb.Create().After("gorm:create").Register("otel:after_create", after); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("otel:before_query", before("select")); err != nil {
+		return err
+	}
+	if err := cb.Query().After("gorm:query").Register("otel:after_query", after); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("otel:before_update", before("update")); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("otel:after_update", after); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("otel:before_delete", before("delete")); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:delete").Register("otel:after_delete", after); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("otel:before_row", before("row")); err != nil {
+		return err
+	}
+	if err := cb.Row().After("gorm:row").Register("otel:after_row", after); err != nil {
+		return err
+	}
+	if err := cb.Raw().Before("gorm:raw").Register("otel:before_raw", before("raw")); err != nil {
+		return err
+	}
+	return cb.Raw().After("gorm:raw").Register("otel:after_raw", after)
+}
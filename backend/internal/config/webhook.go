@@ -0,0 +1,25 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/shreyashsri79/vitbuddy-backend/internal/webhook"
+)
+
+// ClerkWebhook verifies deliveries to /webhooks/clerk; nil when
+// CLERK_WEBHOOK_SECRET is not set, in which case they are rejected.
+var ClerkWebhook *webhook.Svix
+
+func InitWebhooks() {
+	secret := os.Getenv("CLERK_WEBHOOK_SECRET")
+	if secret == "" {
+		slog.Warn("CLERK_WEBHOOK_SECRET is not set, Clerk webhooks will be rejected")
+		return
+	}
+	v, err := webhook.NewSvix(secret)
+	if err != nil {
+		Fatal("Invalid CLERK_WEBHOOK_SECRET", err)
+	}
+	ClerkWebhook = v
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/config"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+)
+
+// ✅ Download everything stored about the caller as a ZIP of JSON files
+func ExportMyData(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	user.Privacy = user.EffectivePrivacy()
+
+	var (
+		lostFound    []models.LostFound
+		marketplace  []models.MarketplaceItem
+		delibuddy    []models.Delibuddy
+		cabs         []models.Cab
+		rides        []models.CabPassenger
+		uploads      []models.Asset
+		interactions []models.Interaction
+		blocks       []models.Block
+	)
+	queries := []error{
+		withImages(db(c).Preload("Image")).Where("owner_id = ?", user.ID).Order("id").Find(&lostFound).Error,
+		withImages(db(c).Preload("Image")).Where("owner_id = ?", user.ID).Order("id").Find(&marketplace).Error,
+		withImages(db(c)).Where("user_id = ?", user.ID).Order("id").Find(&delibuddy).Error,
+		withImages(db(c)).Where("user_id = ?", user.ID).Order("id").Find(&cabs).Error,
+		db(c).Where("user_id = ?", user.ID).Order("cab_id").Find(&rides).Error,
+		db(c).Where("uploader_id = ?", user.ID).Order("id").Find(&uploads).Error,
+		db(c).Preload("Reviews").Where("owner_id = ? OR partner_id = ?", user.ID, user.ID).Order("id").Find(&interactions).Error,
+		db(c).Where("blocker_id = ?", user.ID).Order("created_at").Find(&blocks).Error,
+	}
+	for _, err := range queries {
+		if err != nil {
+			serverError(c, "Failed to export data", err)
+			return
+		}
+	}
+
+	files := []struct {
+		name string
+		data any
+	}{
+		{"profile.json", user},
+		{"lostfound.json", lostFound},
+		{"marketplace.json", marketplace},
+		{"delibuddy.json", delibuddy},
+		{"cabs.json", cabs},
+		{"cab_rides.json", rides},
+		{"uploads.json", uploads},
+		{"interactions.json", interactions},
+		{"blocks.json", blocks},
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			serverError(c, "Failed to export data", err)
+			return
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			serverError(c, "Failed to export data", err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		serverError(c, "Failed to export data", err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="vitbuddy-export.zip"`)
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
+
+// ✅ Schedule deletion of the caller's account after the grace period
+func RequestAccountDeletion(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	// Asking again keeps the original date
+	if user.DeleteAfter != nil {
+		c.JSON(http.StatusOK, gin.H{"message": "Account deletion already scheduled", "delete_after": user.DeleteAfter})
+		return
+	}
+
+	deleteAfter := time.Now().Add(config.AccountDeletionGrace)
+	if err := db(c).Model(user).Update("delete_after", deleteAfter).Error; err != nil {
+		serverError(c, "Failed to schedule account deletion", err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Account deletion scheduled", "delete_after": deleteAfter})
+}
+
+// ✅ Cancel a pending account deletion
+func CancelAccountDeletion(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	res := db(c).Model(&models.User{}).Where("id = ? AND delete_after IS NOT NULL", user.ID).Update("delete_after", nil)
+	if res.Error != nil {
+		serverError(c, "Failed to cancel account deletion", res.Error)
+		return
+	}
+	if res.RowsAffected == 0 {
+		apierror.Abort(c, apierror.NotFound("No account deletion is scheduled"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Account deletion cancelled"})
+}
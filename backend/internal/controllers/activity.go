@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/middleware"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Statuses of a post in the activity feed
+const (
+	activityActive = "active"
+	activityFull   = "full" // cab without free seats
+	activityPast   = "past" // cab or delibuddy whose date has gone by
+)
+
+const (
+	defaultActivityLimit = 20
+	maxActivityLimit     = 100
+)
+
+// Listing types in the order counts are reported
+var activityTypes = []string{models.ListingCab, models.ListingDelibuddy, models.ListingMarketplace, models.ListingLostFound}
+
+// ActivityItem is one of the caller's posts in any module.
+type ActivityItem struct {
+	ListingType string    `json:"listing_type"`
+	ListingID   uint      `json:"listing_id"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+	Listing     any       `json:"listing"`
+}
+
+type activityRef struct {
+	ListingType string
+	ListingID   uint
+	CreatedAt   time.Time
+}
+
+// ✅ Get the caller's posts across all modules, newest first
+func GetMyActivity(c *gin.Context) {
+	userID := middleware.UserID(c)
+	limit, ok := queryInt(c, "limit", defaultActivityLimit, 1, maxActivityLimit)
+	if !ok {
+		return
+	}
+	offset, ok := queryInt(c, "offset", 0, 0, -1)
+	if !ok {
+		return
+	}
+	types := activityTypes
+	if t := c.Query("type"); t != "" {
+		if _, known := listingKinds[t]; !known {
+			apierror.Abort(c, apierror.Field("type", "type must be one of: "+strings.Join(activityTypes, ", ")))
+			return
+		}
+		types = []string{t}
+	}
+
+	counts := map[string]int64{}
+	var total int64
+	for _, t := range activityTypes {
+		kind := listingKinds[t]
+		var n int64
+		if err := db(c).Model(kind.model()).Where(kind.ownerColumn+" = ?", userID).Count(&n).Error; err != nil {
+			serverError(c, "Failed to fetch activity", err)
+			return
+		}
+		counts[t] = n
+		if len(types) > 1 || types[0] == t {
+			total += n
+		}
+	}
+
+	// One page of references across the modules, then the posts themselves
+	var parts []string
+	var args []any
+	for _, t := range types {
+		kind := listingKinds[t]
+		parts = append(parts, fmt.Sprintf("SELECT '%s' AS listing_type, id AS listing_id, created_at FROM %s WHERE %s = ?", t, kind.table, kind.ownerColumn))
+		args = append(args, userID)
+	}
+	var refs []activityRef
+	err := db(c).Raw(strings.Join(parts, " UNION ALL ")+" ORDER BY created_at DESC, listing_id DESC LIMIT ? OFFSET ?",
+		append(args, limit, offset)...).Scan(&refs).Error
+	if err != nil {
+		serverError(c, "Failed to fetch activity", err)
+		return
+	}
+	items, err := loadActivity(c, refs)
+	if err != nil {
+		serverError(c, "Failed to fetch activity", err)
+		return
+	}
+
+	var next *int
+	if end := offset + len(refs); int64(end) < total {
+		next = &end
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":        items,
+		"counts":      counts,
+		"total":       total,
+		"next_offset": next,
+	})
+}
+
+// Loads the referenced posts with their images, keeping the order of refs
+func loadActivity(c *gin.Context, refs []activityRef) ([]ActivityItem, error) {
+	ids := map[string][]uint{}
+	for _, r := range refs {
+		ids[r.ListingType] = append(ids[r.ListingType], r.ListingID)
+	}
+
+	type key struct {
+		listingType string
+		id          uint
+	}
+	found := map[key]ActivityItem{}
+	add := func(listingType string, id uint, status string, createdAt time.Time, listing any) {
+		found[key{listingType, id}] = ActivityItem{listingType, id, status, createdAt, listing}
+	}
+	for t, list := range ids {
+		switch t {
+		case models.ListingCab:
+			var rows []models.Cab
+			if err := withImages(withOwner(db(c))).Find(&rows, list).Error; err != nil {
+				return nil, err
+			}
+			for _, r := range rows {
+				status := activityActive
+				if datePassed(r.Date) {
+					status = activityPast
+				} else if r.SeatsAvailable <= 0 {
+					status = activityFull
+				}
+				add(t, r.ID, status, r.CreatedAt, r)
+			}
+		case models.ListingDelibuddy:
+			var rows []models.Delibuddy
+			if err := withImages(withOwner(db(c))).Find(&rows, list).Error; err != nil {
+				return nil, err
+			}
+			for _, r := range rows {
+				status := activityActive
+				if datePassed(r.Date) {
+					status = activityPast
+				}
+				add(t, r.ID, status, r.CreatedAt, r)
+			}
+		case models.ListingMarketplace:
+			var rows []models.MarketplaceItem
+			if err := withImages(withOwner(db(c)).Preload("Image")).Find(&rows, list).Error; err != nil {
+				return nil, err
+			}
+			for _, r := range rows {
+				add(t, r.ID, activityActive, r.CreatedAt, r)
+			}
+		case models.ListingLostFound:
+			var rows []models.LostFound
+			if err := withImages(withOwner(db(c)).Preload("Image")).Find(&rows, list).Error; err != nil {
+				return nil, err
+			}
+			for _, r := range rows {
+				add(t, r.ID, activityActive, r.CreatedAt, r)
+			}
+		}
+	}
+
+	// A post deleted between the two queries is left out
+	items := make([]ActivityItem, 0, len(refs))
+	for _, r := range refs {
+		if item, ok := found[key{r.ListingType, r.ListingID}]; ok {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// Before today, by calendar day in the date's own time zone (as future_date)
+func datePassed(t time.Time) bool {
+	return t.Format(time.DateOnly) < time.Now().In(t.Location()).Format(time.DateOnly)
+}
+
+// Narrows a list to the caller's own posts with ?mine=true; responds 401
+// when nobody is signed in
+func mineOnly(c *gin.Context, q *gorm.DB, ownerColumn string) (*gorm.DB, bool) {
+	if c.Query("mine") != "true" {
+		return q, true
+	}
+	userID := middleware.UserID(c)
+	if userID == "" {
+		c.Header("WWW-Authenticate", `Bearer realm="vitbuddy"`)
+		apierror.Abort(c, apierror.Unauthorized("Sign in to list your own posts"))
+		return nil, false
+	}
+	c.Header("Vary", "Authorization")
+	return q.Where(ownerColumn+" = ?", userID), true
+}
+
+// Reads an integer query parameter within [lo, hi] (hi < 0: no upper
+// bound), def when absent; responds 400 otherwise
+func queryInt(c *gin.Context, name string, def, lo, hi int) (int, bool) {
+	v, ok := c.GetQuery(name)
+	if !ok {
+		return def, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < lo || (hi >= 0 && n > hi) {
+		msg := fmt.Sprintf("%s must be an integer of at least %d", name, lo)
+		if hi >= 0 {
+			msg = fmt.Sprintf("%s must be an integer from %d to %d", name, lo, hi)
+		}
+		apierror.Abort(c, apierror.Field(name, msg))
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/dto"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/middleware"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Leaves listings of users the caller blocked or muted, and of users who
+// blocked the caller, out of a list; ownerColumn names the listing's owner
+func withoutBlocked(c *gin.Context, q *gorm.DB, ownerColumn string) *gorm.DB {
+	userID := middleware.UserID(c)
+	if userID == "" {
+		return q
+	}
+	c.Header("Vary", "Authorization")
+	hidden := db(c).Model(&models.Block{}).Select("blocked_id").Where("blocker_id = ?", userID)
+	hiding := db(c).Model(&models.Block{}).Select("blocker_id").Where("blocked_id = ? AND kind = ?", userID, models.BlockFull)
+	return q.Where(ownerColumn+" NOT IN (?) AND "+ownerColumn+" NOT IN (?)", hidden, hiding)
+}
+
+// Whether either user blocked the other; muting does not count
+func blockedBetween(c *gin.Context, a, b string) (bool, error) {
+	var n int64
+	err := db(c).Model(&models.Block{}).
+		Where("kind = ? AND ((blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?))", models.BlockFull, a, b, b, a).
+		Count(&n).Error
+	return n > 0, err
+}
+
+// Responds 404 with msg when the caller and ownerID blocked each other, as
+// if the listing did not exist; reports whether the caller may see it
+func checkNotBlocked(c *gin.Context, ownerID, msg string) bool {
+	c.Header("Vary", "Authorization")
+	userID := middleware.UserID(c)
+	if userID == "" || userID == ownerID {
+		return true
+	}
+	blocked, err := blockedBetween(c, userID, ownerID)
+	if err != nil {
+		serverError(c, "Failed to check blocked users", err)
+		return false
+	}
+	if blocked {
+		apierror.Abort(c, apierror.NotFound(msg))
+		return false
+	}
+	return true
+}
+
+// ✅ List the users the caller blocked or muted, newest first
+func GetMyBlocks(c *gin.Context) {
+	blocks := []models.Block{}
+	err := db(c).Preload("Blocked").Where("blocker_id = ?", middleware.UserID(c)).
+		Order("created_at desc").Find(&blocks).Error
+	if err != nil {
+		serverError(c, "Failed to fetch blocked users", err)
+		return
+	}
+	respondWithContentETag(c, blocks)
+}
+
+// ✅ Block or mute a user; blocking again with the other kind changes it
+func PutBlock(c *gin.Context) {
+	var req dto.BlockRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	blockedID := c.Param("id")
+	if blockedID == user.ID {
+		apierror.Abort(c, apierror.Validation("You cannot block yourself"))
+		return
+	}
+
+	block := models.Block{BlockerID: user.ID, BlockedID: blockedID, Kind: req.Kind}
+	created := false
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		var users int64
+		if err := tx.Model(&models.User{}).Where("id = ?", blockedID).Count(&users).Error; err != nil {
+			return err
+		}
+		if users == 0 {
+			return apierror.NotFound("User not found")
+		}
+		var existing int64
+		if err := tx.Model(&models.Block{}).Where("blocker_id = ? AND blocked_id = ?", user.ID, blockedID).Count(&existing).Error; err != nil {
+			return err
+		}
+		created = existing == 0
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "blocker_id"}, {Name: "blocked_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"kind", "updated_at"}),
+		}).Omit(clause.Associations).Create(&block).Error
+		if err != nil {
+			return err
+		}
+		// Reload for the stored created_at of a changed block
+		return tx.Preload("Blocked").Where("blocker_id = ? AND blocked_id = ?", user.ID, blockedID).First(&block).Error
+	})
+	if !txError(c, err, "Failed to block user") {
+		return
+	}
+
+	status, message := http.StatusOK, "Block updated"
+	if created {
+		status, message = http.StatusCreated, "User blocked"
+		if block.Kind == models.BlockMute {
+			message = "User muted"
+		}
+	}
+	c.JSON(status, gin.H{"message": message, "data": block})
+}
+
+// ✅ Unblock or unmute a user
+func DeleteBlock(c *gin.Context) {
+	res := db(c).Where("blocker_id = ? AND blocked_id = ?", middleware.UserID(c), c.Param("id")).Delete(&models.Block{})
+	if res.Error != nil {
+		serverError(c, "Failed to unblock user", res.Error)
+		return
+	}
+	if res.RowsAffected == 0 {
+		apierror.Abort(c, apierror.NotFound("You have not blocked or muted this user"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User unblocked"})
+}
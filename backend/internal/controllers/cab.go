@@ -1,51 +1,64 @@
 package controllers
 
 import (
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
 	"github.com/shreyashsri79/vitbuddy-backend/internal/config"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/dto"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/middleware"
 	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Create cab post
 func CreateCab(c *gin.Context) {
-	var input models.Cab
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req dto.CreateCabRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	// Required fields
-	if input.UserID == "" || input.Username == "" || input.FromLocation == "" || input.ToLocation == "" || input.Date.IsZero() || input.SeatsAvailable <= 0 || input.Phone == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing required fields"})
+	// Female-only rides go by the poster's profile, never by the request
+	if req.FemaleOnly && !checkFemaleOnly(c) {
 		return
 	}
 
-	// Female-only logic: only female users can enable
-	if input.FemaleOnly && input.Gender != "female" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Only female users can enable FemaleOnly rides"})
-		return
+	post := req.Model(middleware.UserID(c))
+	if post.FemaleOnly {
+		post.Gender = models.GenderFemale
 	}
-
-	if err := config.DB.Create(&input).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create cab post"})
+	if err := db(c).Create(&post).Error; err != nil {
+		serverError(c, "Failed to create cab post", err)
 		return
 	}
+	post.Images = []models.AssetAttachment{}
+	post.Owner = userSummary(c, post.UserID)
 
-	c.JSON(http.StatusOK, gin.H{"message": "Cab post created", "data": input})
+	c.JSON(http.StatusOK, gin.H{"message": "Cab post created", "data": post})
 }
 
-// Get cab posts (filters: from/to/date with female-only filtering)
+// Get cab posts (filters: from/to/date; female-only rides only for those who may join them)
 func GetCabs(c *gin.Context) {
 	var posts []models.Cab
 	from := c.Query("from")
 	to := c.Query("to")
 	dateStr := c.Query("date")
-	currentUserGender := c.Query("gender")
 
-	query := config.DB.Order("created_at desc")
+	eligible, err := canRideFemaleOnly(c)
+	if err != nil {
+		serverError(c, "Failed to fetch cab posts", err)
+		return
+	}
+
+	query := withoutBlocked(c, withImages(withOwner(db(c))), "user_id").Order("created_at desc")
+	query, ok := mineOnly(c, query, "user_id")
+	if !ok {
+		return
+	}
 
 	if from != "" {
 		query = query.Where("from_location = ?", from)
@@ -59,71 +72,225 @@ func GetCabs(c *gin.Context) {
 		}
 	}
 
-	if currentUserGender != "female" {
-		query = query.Where("female_only = false")
+	// Posters always see their own rides
+	if !eligible {
+		query = query.Where("female_only = false OR user_id = ?", middleware.UserID(c))
+	}
+
+	if err := query.Find(&posts).Error; err != nil {
+		serverError(c, "Failed to fetch cab posts", err)
+		return
+	}
+	c.Header("Vary", "Authorization")
+	respondWithContentETag(c, posts)
+}
+
+// Get single cab post
+func GetCabByID(c *gin.Context) {
+	post, ok := findVisibleCab(c)
+	if !ok {
+		return
+	}
+	c.Header("Vary", "Authorization")
+	respondWithETag(c, listingETag(post.ID, post.Version, post.Owner), post)
+}
+
+// Loads the cab in the path; female-only rides are 404 to those who may not
+// join them, as in the list, and so are rides of blocked users
+func findVisibleCab(c *gin.Context) (*models.Cab, bool) {
+	var post models.Cab
+	if err := withImages(withOwner(db(c))).First(&post, "id = ?", c.Param("id")).Error; err != nil {
+		apierror.Abort(c, apierror.NotFound("Post not found"))
+		return nil, false
 	}
+	if post.FemaleOnly && post.UserID != middleware.UserID(c) {
+		eligible, err := canRideFemaleOnly(c)
+		if err != nil {
+			serverError(c, "Failed to load cab post", err)
+			return nil, false
+		}
+		if !eligible {
+			apierror.Abort(c, apierror.NotFound("Post not found"))
+			return nil, false
+		}
+	}
+	if !checkNotBlocked(c, post.UserID, "Post not found") {
+		return nil, false
+	}
+	return &post, true
+}
 
-	query.Find(&posts)
-	c.JSON(http.StatusOK, posts)
+// Responds 400 on female_only unless the caller may post female-only rides
+func checkFemaleOnly(c *gin.Context) bool {
+	eligible, err := canRideFemaleOnly(c)
+	if err != nil {
+		serverError(c, "Failed to check your profile", err)
+		return false
+	}
+	if !eligible {
+		msg := "Only women can post female-only rides; set your gender on your profile"
+		if config.FemaleOnlyNeedsReview {
+			msg += " and wait for an admin to verify it"
+		}
+		apierror.Abort(c, apierror.Field("female_only", msg))
+		return false
+	}
+	return true
 }
 
 // Update cab post (owner only)
 func UpdateCab(c *gin.Context) {
 	id := c.Param("id")
-	userID := c.Query("user_id")
+	userID := middleware.UserID(c)
 
 	var post models.Cab
-	if err := config.DB.First(&post, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+	if err := withImages(withOwner(db(c))).First(&post, "id = ?", id).Error; err != nil {
+		apierror.Abort(c, apierror.NotFound("Post not found"))
 		return
 	}
 
 	if post.UserID != userID {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not allowed to update"})
+		apierror.Abort(c, apierror.Forbidden("Not allowed to update"))
 		return
 	}
 
-	var input models.Cab
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !checkIfMatch(c, listingETag(post.ID, post.Version, post.Owner)) {
 		return
 	}
 
-	// Female-only rule during update
-	if input.FemaleOnly && post.Gender != "female" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Only female users can enable FemaleOnly rides"})
+	var input dto.CabPatch
+	updates, ok := bindPatch(c, &input, dto.CabPatchFields)
+	if !ok {
 		return
 	}
 
-	// Partial update fields
-	config.DB.Model(&post).Updates(map[string]interface{}{
-		"from_location":   input.FromLocation,
-		"to_location":     input.ToLocation,
-		"date":            input.Date,
-		"seats_available": input.SeatsAvailable,
-		"phone":           input.Phone,
-		"female_only":     input.FemaleOnly,
-	})
+	// Same female-only rule as on create; turning it off is always allowed
+	if _, set := updates["female_only"]; set {
+		if input.FemaleOnly && !post.FemaleOnly && !checkFemaleOnly(c) {
+			return
+		}
+		updates["gender"] = ""
+		if input.FemaleOnly {
+			updates["gender"] = models.GenderFemale
+		}
+	}
 
+	// The free seats follow from the new size and the passengers who joined;
+	// a join or leave in between bumps the version and fails the update
+	if _, set := updates["seats"]; set {
+		var joined int64
+		if err := db(c).Model(&models.CabPassenger{}).Where("cab_id = ?", post.ID).Count(&joined).Error; err != nil {
+			serverError(c, "Failed to count passengers", err)
+			return
+		}
+		if int64(input.Seats) < joined {
+			apierror.Abort(c, apierror.Conflict(fmt.Sprintf("%d passengers already joined this ride", joined)))
+			return
+		}
+		updates["seats_available"] = input.Seats - int(joined)
+	}
+
+	if !updateVersioned(c, &post, &post.Version, updates, "Failed to update cab post") {
+		return
+	}
+
+	c.Header("ETag", listingETag(post.ID, post.Version, post.Owner))
 	c.JSON(http.StatusOK, gin.H{"message": "Cab post updated", "data": post})
 }
 
 // Delete cab post (owner only)
 func DeleteCab(c *gin.Context) {
 	id := c.Param("id")
-	userID := c.Query("user_id")
+	userID := middleware.UserID(c)
 
 	var post models.Cab
-	if err := config.DB.First(&post, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+	if err := withImages(withOwner(db(c))).First(&post, "id = ?", id).Error; err != nil {
+		apierror.Abort(c, apierror.NotFound("Post not found"))
 		return
 	}
 
 	if post.UserID != userID {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not allowed to delete"})
+		apierror.Abort(c, apierror.Forbidden("Not allowed to delete"))
 		return
 	}
 
-	config.DB.Delete(&post)
+	if !checkIfMatch(c, listingETag(post.ID, post.Version, post.Owner)) {
+		return
+	}
+
+	if !deleteVersioned(c, &post, models.ListingCab, post.ID, post.Version, "Failed to delete cab post") {
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Cab post deleted"})
 }
+
+// Join a cab, taking one of its seats
+func JoinCab(c *gin.Context) {
+	userID := middleware.UserID(c)
+	post, ok := findVisibleCab(c)
+	if !ok {
+		return
+	}
+	if post.UserID == userID {
+		apierror.Abort(c, apierror.Conflict("You posted this ride"))
+		return
+	}
+
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.CabPassenger{CabID: post.ID, UserID: userID})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return apierror.Conflict("You already joined this ride")
+		}
+		res = tx.Model(&models.Cab{}).Where("id = ? AND seats_available > 0", post.ID).
+			Updates(withVersionBump(map[string]any{"seats_available": gorm.Expr("seats_available - 1")}))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return apierror.Conflict("No seats left")
+		}
+		return nil
+	})
+	if !txError(c, err, "Failed to join ride") {
+		return
+	}
+	respondWithSeats(c, post, "Joined ride")
+}
+
+// Leave a joined cab, giving the seat back
+func LeaveCab(c *gin.Context) {
+	var post models.Cab
+	if err := withImages(withOwner(db(c))).First(&post, "id = ?", c.Param("id")).Error; err != nil {
+		apierror.Abort(c, apierror.NotFound("Post not found"))
+		return
+	}
+
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("cab_id = ? AND user_id = ?", post.ID, middleware.UserID(c)).Delete(&models.CabPassenger{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return apierror.NotFound("You have not joined this ride")
+		}
+		return tx.Model(&models.Cab{}).Where("id = ?", post.ID).
+			Updates(withVersionBump(map[string]any{"seats_available": gorm.Expr("seats_available + 1")})).Error
+	})
+	if !txError(c, err, "Failed to leave ride") {
+		return
+	}
+	respondWithSeats(c, &post, "Left ride")
+}
+
+// Reloads the seat count and version a join or leave changed
+func respondWithSeats(c *gin.Context, post *models.Cab, message string) {
+	if err := db(c).Select("seats_available", "version", "updated_at").First(post, post.ID).Error; err != nil {
+		serverError(c, "Failed to load cab post", err)
+		return
+	}
+	c.Header("ETag", listingETag(post.ID, post.Version, post.Owner))
+	c.JSON(http.StatusOK, gin.H{"message": message, "data": post})
+}
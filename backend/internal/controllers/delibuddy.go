@@ -5,37 +5,28 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/shreyashsri79/vitbuddy-backend/internal/config"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/dto"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/middleware"
 	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
 )
 
 // Create Delibuddy entry (delivery offer or request)
 func CreateDelibuddy(c *gin.Context) {
-	var input models.Delibuddy
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format"})
+	var req dto.CreateDelibuddyRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	// Validate required fields
-	if input.Type == "" || input.UserID == "" || input.Username == "" || input.Location == "" || input.Phone == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing required fields"})
+	entry := req.Model(middleware.UserID(c))
+	if err := db(c).Create(&entry).Error; err != nil {
+		serverError(c, "Failed to create entry", err)
 		return
 	}
+	entry.Images = []models.AssetAttachment{}
+	entry.Owner = userSummary(c, entry.UserID)
 
-	// Validate type
-	input.Type = strings.ToLower(input.Type)
-	if input.Type != "offer" && input.Type != "request" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Type must be 'offer' or 'request'"})
-		return
-	}
-
-	if err := config.DB.Create(&input).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create entry"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Entry created", "data": input})
+	c.JSON(http.StatusOK, gin.H{"message": "Entry created", "data": entry})
 }
 
 // Get all Delibuddy posts
@@ -43,102 +34,100 @@ func GetDelibuddy(c *gin.Context) {
 	var entries []models.Delibuddy
 	entryType := c.Query("type")
 
-	query := config.DB.Order("created_at desc")
+	query := withoutBlocked(c, withImages(withOwner(db(c))), "user_id").Order("created_at desc")
+	query, ok := mineOnly(c, query, "user_id")
+	if !ok {
+		return
+	}
 
 	if entryType != "" {
 		entryType = strings.ToLower(entryType)
 		if entryType != "offer" && entryType != "request" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type filter"})
+			apierror.Abort(c, apierror.Field("type", "Invalid type filter"))
 			return
 		}
 		query = query.Where("type = ?", entryType)
 	}
 
-	query.Find(&entries)
-	c.JSON(http.StatusOK, entries)
+	if err := query.Find(&entries).Error; err != nil {
+		serverError(c, "Failed to fetch entries", err)
+		return
+	}
+	respondWithContentETag(c, entries)
 }
 
+// Get single Delibuddy entry
+func GetDelibuddyByID(c *gin.Context) {
+	id := c.Param("id")
+
+	var entry models.Delibuddy
+	if err := withImages(withOwner(db(c))).First(&entry, "id = ?", id).Error; err != nil {
+		apierror.Abort(c, apierror.NotFound("Entry not found"))
+		return
+	}
+	if !checkNotBlocked(c, entry.UserID, "Entry not found") {
+		return
+	}
+
+	respondWithETag(c, listingETag(entry.ID, entry.Version, entry.Owner), entry)
+}
 
 // Update Delibuddy entry (owner only)
 func UpdateDelibuddy(c *gin.Context) {
 	id := c.Param("id")
-	userID := c.Query("user_id")
+	userID := middleware.UserID(c)
 
 	var entry models.Delibuddy
-	if err := config.DB.First(&entry, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+	if err := withImages(withOwner(db(c))).First(&entry, "id = ?", id).Error; err != nil {
+		apierror.Abort(c, apierror.NotFound("Entry not found"))
 		return
 	}
 
 	if entry.UserID != userID {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not allowed to update"})
+		apierror.Abort(c, apierror.Forbidden("Not allowed to update"))
 		return
 	}
 
-	var input models.Delibuddy
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format"})
+	if !checkIfMatch(c, listingETag(entry.ID, entry.Version, entry.Owner)) {
 		return
 	}
 
-	// Validate type if provided
-	if input.Type != "" {
-		input.Type = strings.ToLower(input.Type)
-		if input.Type != "offer" && input.Type != "request" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Type must be 'offer' or 'request'"})
-			return
-		}
-	}
-
-	// Apply partial update
-	updateData := map[string]interface{}{}
-
-	if input.Type != "" {
-		updateData["type"] = input.Type
-	}
-	if input.PriceOffered != 0 {
-		updateData["price_offered"] = input.PriceOffered
-	}
-	if input.Location != "" {
-		updateData["location"] = input.Location
-	}
-	if !input.Date.IsZero() {
-		updateData["date"] = input.Date
-	}
-	if input.TimeSlot != "" {
-		updateData["time_slot"] = input.TimeSlot
-	}
-	if input.Phone != "" {
-		updateData["phone"] = input.Phone
+	var input dto.DelibuddyPatch
+	updates, ok := bindPatch(c, &input, dto.DelibuddyPatchFields)
+	if !ok {
+		return
 	}
 
-	if len(updateData) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No valid fields to update"})
+	if !updateVersioned(c, &entry, &entry.Version, updates, "Failed to update entry") {
 		return
 	}
 
-	config.DB.Model(&entry).Updates(updateData)
-
+	c.Header("ETag", listingETag(entry.ID, entry.Version, entry.Owner))
 	c.JSON(http.StatusOK, gin.H{"message": "Entry updated", "data": entry})
 }
 
-
 // Delete Delibuddy entry
 func DeleteDelibuddy(c *gin.Context) {
 	id := c.Param("id")
-	userID := c.Query("user_id")
+	userID := middleware.UserID(c)
 
 	var entry models.Delibuddy
-	if err := config.DB.First(&entry, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+	if err := withImages(withOwner(db(c))).First(&entry, "id = ?", id).Error; err != nil {
+		apierror.Abort(c, apierror.NotFound("Entry not found"))
 		return
 	}
 
 	if entry.UserID != userID {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not allowed to delete"})
+		apierror.Abort(c, apierror.Forbidden("Not allowed to delete"))
 		return
 	}
 
-	config.DB.Delete(&entry)
+	if !checkIfMatch(c, listingETag(entry.ID, entry.Version, entry.Owner)) {
+		return
+	}
+
+	if !deleteVersioned(c, &entry, models.ListingDelibuddy, entry.ID, entry.Version, "Failed to delete entry") {
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Entry deleted"})
 }
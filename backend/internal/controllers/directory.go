@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/dto"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ✅ List hostels
+func GetHostels(c *gin.Context) {
+	var hostels []models.Hostel
+	if err := db(c).Order("code").Find(&hostels).Error; err != nil {
+		serverError(c, "Failed to fetch hostels", err)
+		return
+	}
+	respondWithContentETag(c, hostels)
+}
+
+// ✅ Create or replace a hostel (admin only)
+func PutHostel(c *gin.Context) {
+	var req dto.HostelRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	hostel := models.Hostel{Code: c.Param("code"), Name: req.Name, Blocks: req.Blocks}
+	if hostel.Blocks == nil {
+		hostel.Blocks = []string{}
+	}
+	putEntry(c, &hostel, hostel.Code, "Hostel", []string{"name", "blocks", "updated_at"})
+}
+
+// ✅ Delete a hostel no profile uses (admin only)
+func DeleteHostel(c *gin.Context) {
+	deleteEntry(c, &models.Hostel{}, "hostel", "Hostel")
+}
+
+// ✅ List programmes
+func GetProgrammes(c *gin.Context) {
+	var programmes []models.Programme
+	if err := db(c).Order("code").Find(&programmes).Error; err != nil {
+		serverError(c, "Failed to fetch programmes", err)
+		return
+	}
+	respondWithContentETag(c, programmes)
+}
+
+// ✅ Create or replace a programme (admin only)
+func PutProgramme(c *gin.Context) {
+	var req dto.ProgrammeRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	programme := models.Programme{Code: c.Param("code"), Name: req.Name, Years: req.Years}
+	putEntry(c, &programme, programme.Code, "Programme", []string{"name", "years", "updated_at"})
+}
+
+// ✅ Delete a programme no profile uses (admin only)
+func DeleteProgramme(c *gin.Context) {
+	deleteEntry(c, &models.Programme{}, "programme", "Programme")
+}
+
+// Upserts a list entry by code; 201 when it is new, 200 when replaced
+func putEntry(c *gin.Context, entry any, code, noun string, columns []string) {
+	created := false
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(entry).Where("code = ?", code).Count(&existing).Error; err != nil {
+			return err
+		}
+		created = existing == 0
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "code"}},
+			DoUpdates: clause.AssignmentColumns(columns),
+		}).Create(entry).Error
+		if err != nil {
+			return err
+		}
+		// Reload for the stored created_at of a replaced entry
+		return tx.Where("code = ?", code).First(entry).Error
+	})
+	if err != nil {
+		serverError(c, "Failed to save "+noun, err)
+		return
+	}
+
+	status, message := http.StatusOK, noun+" updated"
+	if created {
+		status, message = http.StatusCreated, noun+" created"
+	}
+	c.JSON(status, gin.H{"message": message, "data": entry})
+}
+
+// Deletes a list entry by code unless a profile still refers to it through
+// the users column of the same name
+func deleteEntry(c *gin.Context, entry any, column, noun string) {
+	code := c.Param("code")
+
+	var inUse int64
+	if err := db(c).Model(&models.User{}).Where(column+" = ?", code).Count(&inUse).Error; err != nil {
+		serverError(c, "Failed to delete "+noun, err)
+		return
+	}
+	if inUse > 0 {
+		apierror.Abort(c, apierror.Conflict(fmt.Sprintf("%s is still used by profiles (%d)", noun, inUse)))
+		return
+	}
+
+	res := db(c).Where("code = ?", code).Delete(entry)
+	if res.Error != nil {
+		serverError(c, "Failed to delete "+noun, res.Error)
+		return
+	}
+	if res.RowsAffected == 0 {
+		apierror.Abort(c, apierror.NotFound(noun+" not found"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": noun + " deleted"})
+}
@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/imageproc"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/middleware"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Photos of the same thing hash at most this many of 64 bits apart;
+// unrelated photos are usually around 32
+const similarDistance = 10
+
+const maxSimilarListings = 20
+
+// Only the images most recently attached to listings are compared, which
+// keeps a lookup bounded as listings pile up; reposts are rarely older
+const similarCandidates = 2000
+
+// SimilarListing is another listing with an image that looks like one of ours.
+type SimilarListing struct {
+	ListingType string `json:"listing_type"`
+	ListingID   uint   `json:"listing_id"`
+	OwnerID     string `json:"owner_id"`
+	AssetID     uint   `json:"asset_id"`
+	Distance    int    `json:"distance"` // differing hash bits, 0 is identical
+}
+
+type hashedImage struct {
+	ListingID uint
+	OwnerID   string
+	AssetID   uint
+	PHash     int64
+}
+
+// Other listings of listingType whose images are within similarDistance of
+// an image of the given listing, closest first, among the newest
+// similarCandidates and leaving out those the caller may not see because of
+// blocks. filter narrows the candidates and may refer to the listing table
+// as "l".
+func similarListings(c *gin.Context, listingType string, id uint, filter func(*gorm.DB) *gorm.DB) ([]SimilarListing, error) {
+	tx := db(c)
+	var own []int64
+	err := tx.Model(&models.AssetAttachment{}).
+		Joins("JOIN assets ON assets.id = asset_attachments.asset_id").
+		Where("asset_attachments.listing_type = ? AND asset_attachments.listing_id = ? AND assets.p_hash IS NOT NULL", listingType, id).
+		Pluck("assets.p_hash", &own).Error
+	if err != nil || len(own) == 0 {
+		return []SimilarListing{}, err
+	}
+
+	kind := listingKinds[listingType]
+	q := tx.Table("asset_attachments").
+		Select("asset_attachments.listing_id, asset_attachments.asset_id, assets.p_hash, l."+kind.ownerColumn+" AS owner_id").
+		Joins("JOIN assets ON assets.id = asset_attachments.asset_id").
+		Joins("JOIN "+kind.table+" l ON l.id = asset_attachments.listing_id").
+		Where("asset_attachments.listing_type = ? AND asset_attachments.listing_id <> ? AND assets.p_hash IS NOT NULL", listingType, id)
+	q = withoutBlocked(c, q, "l."+kind.ownerColumn)
+	if filter != nil {
+		q = filter(q)
+	}
+	q = q.Order("asset_attachments.id DESC").Limit(similarCandidates)
+	var others []hashedImage
+	if err := q.Scan(&others).Error; err != nil {
+		return nil, err
+	}
+
+	// Keep each listing's closest image
+	best := map[uint]SimilarListing{}
+	for _, o := range others {
+		for _, h := range own {
+			d := imageproc.Distance(uint64(h), uint64(o.PHash))
+			if d > similarDistance {
+				continue
+			}
+			if prev, ok := best[o.ListingID]; !ok || d < prev.Distance {
+				best[o.ListingID] = SimilarListing{listingType, o.ListingID, o.OwnerID, o.AssetID, d}
+			}
+		}
+	}
+
+	out := make([]SimilarListing, 0, len(best))
+	for _, s := range best {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Distance != out[j].Distance {
+			return out[i].Distance < out[j].Distance
+		}
+		return out[i].ListingID > out[j].ListingID
+	})
+	if len(out) > maxSimilarListings {
+		out = out[:maxSimilarListings]
+	}
+	return out, nil
+}
+
+// Lost/found entries of the same category are duplicates, the other
+// category are possible matches
+func lostFoundSimilar(c *gin.Context, id uint, category string, sameCategory bool) ([]SimilarListing, error) {
+	want := category
+	if !sameCategory {
+		want = models.CategoryLost
+		if category == models.CategoryLost {
+			want = models.CategoryFound
+		}
+	}
+	return similarListings(c, models.ListingLostFound, id, func(q *gorm.DB) *gorm.DB {
+		return q.Where("l.category = ?", want)
+	})
+}
+
+// Looks for reposts of a listing's images to show the poster. A failed
+// lookup is logged rather than failing the request that triggered it.
+func duplicatesForPoster(c *gin.Context, listingType string, id uint) []SimilarListing {
+	var (
+		dups []SimilarListing
+		err  error
+	)
+	switch listingType {
+	case models.ListingLostFound:
+		var categories []string
+		if err = db(c).Model(&models.LostFound{}).Where("id = ?", id).Pluck("category", &categories).Error; err == nil && len(categories) > 0 {
+			dups, err = lostFoundSimilar(c, id, categories[0], true)
+		}
+	case models.ListingMarketplace:
+		dups, err = similarListings(c, listingType, id, nil)
+	default:
+		return nil
+	}
+	if dups == nil {
+		dups = []SimilarListing{}
+	}
+	if err != nil {
+		middleware.Log(c).Warn("Duplicate image lookup failed", "listing_type", listingType, "listing_id", id, "error", err)
+		return []SimilarListing{}
+	}
+	return dups
+}
+
+func parseListingID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		apierror.Abort(c, apierror.Field("id", "id must be a positive integer"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetMarketplaceDuplicates lists other items whose photos look like this one's
+func GetMarketplaceDuplicates(c *gin.Context) {
+	id, ok := parseListingID(c)
+	if !ok {
+		return
+	}
+	var item models.MarketplaceItem
+	if err := db(c).Select("id", "owner_id").First(&item, id).Error; err != nil {
+		apierror.Abort(c, apierror.NotFound("Item not found"))
+		return
+	}
+	if !checkNotBlocked(c, item.OwnerID, "Item not found") {
+		return
+	}
+
+	dups, err := similarListings(c, models.ListingMarketplace, id, nil)
+	if err != nil {
+		serverError(c, "Failed to look up similar items", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": dups})
+}
+
+// GetLostFoundSimilar lists entries whose photos look like this one's: the
+// same category (duplicates) or, with matches, the other one (a lost item
+// and the report of it being found)
+func GetLostFoundSimilar(matches bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseListingID(c)
+		if !ok {
+			return
+		}
+		var item models.LostFound
+		if err := db(c).Select("id", "category", "owner_id").First(&item, id).Error; err != nil {
+			apierror.Abort(c, apierror.NotFound("Item not found"))
+			return
+		}
+		if !checkNotBlocked(c, item.OwnerID, "Item not found") {
+			return
+		}
+
+		similar, err := lostFoundSimilar(c, id, item.Category, !matches)
+		if err != nil {
+			serverError(c, "Failed to look up similar entries", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": similar})
+	}
+}
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Strong ETag for a single listing; changes whenever its version is bumped
+// or the owner embedded in it changes (username, avatar, reputation)
+func listingETag(id, version uint, owner *models.UserSummary) string {
+	if owner == nil {
+		return fmt.Sprintf(`"%d-%d"`, id, version)
+	}
+	data, _ := json.Marshal(owner)
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf(`"%d-%d-%s"`, id, version, hex.EncodeToString(sum[:6]))
+}
+
+// Reports whether a comma-separated If-Match / If-None-Match header names etag.
+// weak uses weak comparison (W/ prefixes ignored), as If-None-Match requires.
+func etagMatches(header, etag string, weak bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	if weak {
+		etag = strings.TrimPrefix(etag, "W/")
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		} else if strings.HasPrefix(candidate, "W/") {
+			continue
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// Aborts with 412 when the client sent If-Match for a different version
+func checkIfMatch(c *gin.Context, etag string) bool {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" || etagMatches(ifMatch, etag, false) {
+		return true
+	}
+	apierror.Abort(c, apierror.PreconditionFailed("Resource has changed since it was fetched"))
+	return false
+}
+
+// Applies updates only if the row still has the version we read, bumping it.
+// A concurrent writer makes the WHERE miss, which is reported as 412.
+func updateVersioned(c *gin.Context, model any, version *uint, updates map[string]any, failMsg string) bool {
+	res := db(c).Model(model).Omit(clause.Associations).Where("version = ?", *version).
+		Updates(withVersionBump(updates))
+	if res.Error != nil {
+		serverError(c, failMsg, res.Error)
+		return false
+	}
+	if res.RowsAffected == 0 {
+		apierror.Abort(c, apierror.PreconditionFailed("Resource was modified concurrently, fetch it again"))
+		return false
+	}
+	*version++
+	return true
+}
+
+// Deletes the row and its image attachments only if it still has the version we read
+func deleteVersioned(c *gin.Context, model any, listingType string, id, version uint, failMsg string) bool {
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("version = ?", version).Delete(model)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return apierror.PreconditionFailed("Resource was modified concurrently, fetch it again")
+		}
+		attached := tx.Model(&models.AssetAttachment{}).Select("asset_id").
+			Where("listing_type = ? AND listing_id = ?", listingType, id)
+		if err := setAssetState(tx, models.AssetDetached, attached); err != nil {
+			return err
+		}
+		return tx.Where("listing_type = ? AND listing_id = ?", listingType, id).
+			Delete(&models.AssetAttachment{}).Error
+	})
+	var apiErr *apierror.Error
+	switch {
+	case errors.As(err, &apiErr):
+		apierror.Abort(c, apiErr)
+		return false
+	case err != nil:
+		serverError(c, failMsg, err)
+		return false
+	}
+	return true
+}
+
+func withVersionBump(updates map[string]any) map[string]any {
+	out := make(map[string]any, len(updates)+1)
+	for k, v := range updates {
+		out[k] = v
+	}
+	out["version"] = gorm.Expr("version + 1")
+	return out
+}
+
+// Writes body with etag, or 304 when If-None-Match already names it
+func respondWithETag(c *gin.Context, etag string, body any) {
+	c.Header("ETag", etag)
+	if inm := c.GetHeader("If-None-Match"); inm != "" && etagMatches(inm, etag, true) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// Like respondWithETag, with a weak ETag hashed from the serialized body (for lists)
+func respondWithContentETag(c *gin.Context, body any) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		serverError(c, "Failed to encode response", err)
+		return
+	}
+	sum := sha256.Sum256(data)
+	etag := `W/"` + hex.EncodeToString(sum[:12]) + `"`
+
+	c.Header("ETag", etag)
+	if inm := c.GetHeader("If-None-Match"); inm != "" && etagMatches(inm, etag, true) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/config"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/dto"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/middleware"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Whether the caller may see and join female-only cabs; anonymous callers
+// and users without a profile may not
+func canRideFemaleOnly(c *gin.Context) (bool, error) {
+	userID := middleware.UserID(c)
+	if userID == "" {
+		return false, nil
+	}
+	var users []models.User
+	err := db(c).Select("gender", "gender_attested_at", "gender_review").
+		Where("id = ?", userID).Limit(1).Find(&users).Error
+	if err != nil || len(users) == 0 {
+		return false, err
+	}
+	return users[0].CanRideFemaleOnly(config.FemaleOnlyNeedsReview), nil
+}
+
+// ✅ List female users whose attested gender no admin has reviewed yet, oldest first (admin only)
+func GetPendingGenderReviews(c *gin.Context) {
+	var users []models.User
+	err := db(c).Where("gender = ? AND gender_attested_at IS NOT NULL AND gender_review = ''", models.GenderFemale).
+		Order("gender_attested_at").Find(&users).Error
+	if err != nil {
+		serverError(c, "Failed to fetch pending reviews", err)
+		return
+	}
+	respondWithContentETag(c, users)
+}
+
+// ✅ Verify or reject a user's attested gender (admin only)
+func PutGenderReview(c *gin.Context) {
+	var req dto.GenderReviewRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	user, ok := findReviewedUser(c)
+	if !ok {
+		return
+	}
+	if user.GenderAttestedAt == nil {
+		apierror.Abort(c, apierror.Validation("The user has not given a gender"))
+		return
+	}
+
+	now := time.Now()
+	err := db(c).Model(user).Updates(map[string]any{
+		"gender_review":      req.Status,
+		"gender_reviewed_by": middleware.UserID(c),
+		"gender_reviewed_at": now,
+	}).Error
+	if err != nil {
+		serverError(c, "Failed to save review", err)
+		return
+	}
+	middleware.Log(c).Info("Gender reviewed", "user_id", user.ID, "status", req.Status)
+	c.JSON(http.StatusOK, gin.H{"message": "Review saved", "data": user})
+}
+
+// ✅ Withdraw a review, e.g. to lift a rejection (admin only)
+func DeleteGenderReview(c *gin.Context) {
+	user, ok := findReviewedUser(c)
+	if !ok {
+		return
+	}
+	err := db(c).Model(user).Updates(map[string]any{
+		"gender_review":      "",
+		"gender_reviewed_by": "",
+		"gender_reviewed_at": nil,
+	}).Error
+	if err != nil {
+		serverError(c, "Failed to withdraw review", err)
+		return
+	}
+	middleware.Log(c).Info("Gender review withdrawn", "user_id", user.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Review withdrawn", "data": user})
+}
+
+func findReviewedUser(c *gin.Context) (*models.User, bool) {
+	var user models.User
+	err := db(c).First(&user, "id = ?", c.Param("id")).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Abort(c, apierror.NotFound("User not found"))
+		return nil, false
+	}
+	if err != nil {
+		serverError(c, "Failed to load user", err)
+		return nil, false
+	}
+	return &user, true
+}
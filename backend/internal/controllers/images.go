@@ -0,0 +1,350 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/dto"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/imageproc"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/middleware"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxListingImages caps how many images one listing can show.
+const MaxListingImages = 10
+
+// How each listing type names its owner, and whether it mirrors the cover
+// into the legacy image_asset_id / image_url columns
+type listingKind struct {
+	model       func() any
+	table       string
+	ownerColumn string
+	hasCover    bool
+}
+
+var listingKinds = map[string]listingKind{
+	models.ListingLostFound:   {func() any { return &models.LostFound{} }, "lost_founds", "owner_id", true},
+	models.ListingMarketplace: {func() any { return &models.MarketplaceItem{} }, "marketplace_items", "owner_id", true},
+	models.ListingDelibuddy:   {func() any { return &models.Delibuddy{} }, "delibuddies", "user_id", false},
+	models.ListingCab:         {func() any { return &models.Cab{} }, "cabs", "user_id", false},
+}
+
+// Preloads a listing's images in display order
+func withImages(q *gorm.DB) *gorm.DB {
+	return q.Preload("Images", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).Preload("Images.Asset")
+}
+
+// Preloads a listing's owner summary
+func withOwner(q *gorm.DB) *gorm.DB {
+	return q.Preload("Owner")
+}
+
+// The summary of a user to show on something just created, such as a
+// listing's owner; nil when it cannot be loaded, which only leaves it out of
+// the response
+func userSummary(c *gin.Context, userID string) *models.UserSummary {
+	var user models.UserSummary
+	if err := db(c).First(&user, "id = ?", userID).Error; err != nil {
+		middleware.Log(c).Warn("Failed to load user summary", "user_id", userID, "error", err)
+		return nil
+	}
+	return &user
+}
+
+// Loads an uploaded image; responds 400 on field when it does not exist
+func findAsset(c *gin.Context, field string, id uint) (*models.Asset, bool) {
+	var asset models.Asset
+	err := db(c).First(&asset, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Abort(c, apierror.Field(field, field+" does not refer to an uploaded image"))
+		return nil, false
+	}
+	if err != nil {
+		serverError(c, "Failed to load image", err)
+		return nil, false
+	}
+	return &asset, true
+}
+
+// Only the person who uploaded an image may put it on a listing
+func checkUploader(c *gin.Context, asset *models.Asset, userID string) bool {
+	if asset.UploaderID != userID {
+		apierror.Abort(c, apierror.Forbidden(fmt.Sprintf("Image %d was not uploaded by you", asset.ID)))
+		return false
+	}
+	return true
+}
+
+// Resolves the listing in the URL and checks the caller owns it
+func ownedListing(c *gin.Context, listingType string) (listingKind, uint, bool) {
+	kind := listingKinds[listingType]
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		apierror.Abort(c, apierror.Field("id", "id must be a positive integer"))
+		return kind, 0, false
+	}
+
+	var owners []string
+	if err := db(c).Model(kind.model()).Where("id = ?", id).Pluck(kind.ownerColumn, &owners).Error; err != nil {
+		serverError(c, "Failed to load listing", err)
+		return kind, 0, false
+	}
+	if len(owners) == 0 {
+		apierror.Abort(c, apierror.NotFound("Listing not found"))
+		return kind, 0, false
+	}
+	if owners[0] != middleware.UserID(c) {
+		apierror.Abort(c, apierror.Forbidden("Only the owner can change the images"))
+		return kind, 0, false
+	}
+	return kind, uint(id), true
+}
+
+// Locks the listing row for the rest of the transaction, so concurrent image
+// changes to one listing run one after another and see each other's positions
+func lockListing(tx *gorm.DB, kind listingKind, id uint) error {
+	var ids []uint
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(kind.model()).
+		Where("id = ?", id).Pluck("id", &ids).Error
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return apierror.NotFound("Listing not found")
+	}
+	return nil
+}
+
+func listImages(tx *gorm.DB, listingType string, id uint) ([]models.AssetAttachment, error) {
+	images := []models.AssetAttachment{}
+	err := tx.Preload("Asset").
+		Where("listing_type = ? AND listing_id = ?", listingType, id).
+		Order("position").Find(&images).Error
+	return images, err
+}
+
+// Places an asset on a listing; an asset can only be on one listing
+func attachImage(tx *gorm.DB, listingType string, id uint, asset *models.Asset, position int) error {
+	var taken int64
+	if err := tx.Model(&models.AssetAttachment{}).Where("asset_id = ?", asset.ID).Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return apierror.Conflict("Image is already attached to a listing")
+	}
+	err := tx.Create(&models.AssetAttachment{
+		ListingType: listingType,
+		ListingID:   id,
+		AssetID:     asset.ID,
+		Position:    position,
+	}).Error
+	if err != nil {
+		return err
+	}
+	if err := setAssetState(tx, models.AssetAttached, asset.ID); err != nil {
+		return err
+	}
+	asset.State = models.AssetAttached
+	return nil
+}
+
+// Records whether assets are in use; the orphan collector's grace period
+// counts from the last change
+func setAssetState(tx *gorm.DB, state string, ids any) error {
+	return tx.Model(&models.Asset{}).Where("id IN (?)", ids).
+		Updates(map[string]any{"state": state, "state_since": time.Now()}).Error
+}
+
+// Bumps the listing version (its images are part of its ETag) and mirrors
+// the cover into the legacy single-image columns
+func touchListing(tx *gorm.DB, listingType string, kind listingKind, id uint, images []models.AssetAttachment) error {
+	updates := map[string]any{"version": gorm.Expr("version + 1")}
+	if kind.hasCover {
+		updates["image_asset_id"] = nil
+		updates["image_url"] = ""
+		if len(images) > 0 {
+			updates["image_asset_id"] = images[0].AssetID
+			updates["image_url"] = images[0].Asset.Variants[imageproc.VariantOriginal].URL
+		}
+	}
+	return tx.Model(kind.model()).Where("id = ?", id).Updates(updates).Error
+}
+
+// Renumbers positions 0..n-1 in the given order. Positions are unique per
+// listing, so moved images step aside to negative positions first.
+func savePositions(tx *gorm.DB, images []models.AssetAttachment) error {
+	var moved []int
+	for i := range images {
+		if images[i].Position == i {
+			continue
+		}
+		moved = append(moved, i)
+		if err := tx.Model(&images[i]).Update("position", -1-i).Error; err != nil {
+			return err
+		}
+	}
+	for _, i := range moved {
+		images[i].Position = i
+		if err := tx.Model(&images[i]).Update("position", i).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AttachImage adds one of the caller's uploads to the end of a listing's images
+func AttachImage(listingType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kind, id, ok := ownedListing(c, listingType)
+		if !ok {
+			return
+		}
+
+		var req dto.AttachImageRequest
+		if !bindJSON(c, &req) {
+			return
+		}
+		asset, ok := findAsset(c, "asset_id", req.AssetID)
+		if !ok || !checkUploader(c, asset, middleware.UserID(c)) {
+			return
+		}
+
+		var images []models.AssetAttachment
+		err := db(c).Transaction(func(tx *gorm.DB) error {
+			if err := lockListing(tx, kind, id); err != nil {
+				return err
+			}
+			current, err := listImages(tx, listingType, id)
+			if err != nil {
+				return err
+			}
+			if len(current) >= MaxListingImages {
+				return apierror.Conflict(fmt.Sprintf("A listing can have at most %d images", MaxListingImages))
+			}
+
+			if err := attachImage(tx, listingType, id, asset, len(current)); err != nil {
+				return err
+			}
+			images = append(current, models.AssetAttachment{AssetID: asset.ID, Asset: asset, Position: len(current)})
+			return touchListing(tx, listingType, kind, id, images)
+		})
+		if !txError(c, err, "Failed to attach image") {
+			return
+		}
+
+		resp := gin.H{"message": "Image attached", "data": images}
+		if dups := duplicatesForPoster(c, listingType, id); dups != nil {
+			resp["duplicates"] = dups
+		}
+		c.JSON(http.StatusCreated, resp)
+	}
+}
+
+// ReorderImages sets the display order; the first image becomes the cover
+func ReorderImages(listingType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kind, id, ok := ownedListing(c, listingType)
+		if !ok {
+			return
+		}
+
+		var req dto.ReorderImagesRequest
+		if !bindJSON(c, &req) {
+			return
+		}
+
+		var images []models.AssetAttachment
+		err := db(c).Transaction(func(tx *gorm.DB) error {
+			if err := lockListing(tx, kind, id); err != nil {
+				return err
+			}
+			current, err := listImages(tx, listingType, id)
+			if err != nil {
+				return err
+			}
+
+			byAsset := make(map[uint]models.AssetAttachment, len(current))
+			for _, a := range current {
+				byAsset[a.AssetID] = a
+			}
+			if len(req.AssetIDs) != len(current) {
+				return apierror.Field("asset_ids", "asset_ids must list every attached image exactly once")
+			}
+			for _, assetID := range req.AssetIDs {
+				a, ok := byAsset[assetID]
+				if !ok {
+					return apierror.Field("asset_ids", "asset_ids must list every attached image exactly once")
+				}
+				delete(byAsset, assetID)
+				images = append(images, a)
+			}
+
+			if err := savePositions(tx, images); err != nil {
+				return err
+			}
+			return touchListing(tx, listingType, kind, id, images)
+		})
+		if !txError(c, err, "Failed to reorder images") {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Images reordered", "data": images})
+	}
+}
+
+// DetachImage removes an image from a listing. The upload itself is kept
+// until the orphan collector deletes it.
+func DetachImage(listingType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kind, id, ok := ownedListing(c, listingType)
+		if !ok {
+			return
+		}
+		assetID, err := strconv.ParseUint(c.Param("asset_id"), 10, 64)
+		if err != nil {
+			apierror.Abort(c, apierror.Field("asset_id", "asset_id must be a positive integer"))
+			return
+		}
+
+		var images []models.AssetAttachment
+		err = db(c).Transaction(func(tx *gorm.DB) error {
+			if err := lockListing(tx, kind, id); err != nil {
+				return err
+			}
+			res := tx.Where("listing_type = ? AND listing_id = ? AND asset_id = ?", listingType, id, assetID).
+				Delete(&models.AssetAttachment{})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return apierror.NotFound("Image is not attached to this listing")
+			}
+			if err := setAssetState(tx, models.AssetDetached, assetID); err != nil {
+				return err
+			}
+
+			var err error
+			if images, err = listImages(tx, listingType, id); err != nil {
+				return err
+			}
+			if err := savePositions(tx, images); err != nil {
+				return err
+			}
+			return touchListing(tx, listingType, kind, id, images)
+		})
+		if !txError(c, err, "Failed to detach image") {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Image detached", "data": images})
+	}
+}
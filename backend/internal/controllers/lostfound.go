@@ -5,38 +5,68 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/shreyashsri79/vitbuddy-backend/internal/config"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/dto"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/imageproc"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/middleware"
 	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"gorm.io/gorm"
 )
 
 // Create Lost & Found Post
 func CreateLostFound(c *gin.Context) {
-	var input models.LostFound
-
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+	var req dto.CreateLostFoundRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	// Validate required fields
-	if input.Category == "" || input.OwnerID == "" || input.Phone == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing required fields"})
-		return
+	item := req.Model(middleware.UserID(c))
+	var image *models.Asset
+	if item.ImageAssetID != nil {
+		var ok bool
+		if image, ok = findAsset(c, "image_asset_id", *item.ImageAssetID); !ok {
+			return
+		}
+		if !checkUploader(c, image, item.OwnerID) {
+			return
+		}
+		item.ImageURL = image.Variants[imageproc.VariantOriginal].URL
 	}
 
-	// Validate category ENUM
-	input.Category = strings.ToLower(input.Category)
-	if input.Category != models.CategoryLost && input.Category != models.CategoryFound {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Category must be 'lost' or 'found'"})
+	// The initial image becomes the first attachment, i.e. the cover
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&item).Error; err != nil {
+			return err
+		}
+		if image == nil {
+			return nil
+		}
+		return attachImage(tx, models.ListingLostFound, item.ID, image, 0)
+	})
+	if !txError(c, err, "Failed to create lost/found entry") {
 		return
 	}
+	item.Image = image
+	item.Images = []models.AssetAttachment{}
+	item.Owner = userSummary(c, item.OwnerID)
+	if image != nil {
+		item.Images = append(item.Images, models.AssetAttachment{AssetID: image.ID, Asset: image})
+	}
 
-	if err := config.DB.Create(&input).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create lost/found entry"})
-		return
+	// Point the poster at reports of the same thing: duplicates of their own
+	// category and possible matches from the other one
+	matches, err := lostFoundSimilar(c, item.ID, item.Category, false)
+	if err != nil {
+		middleware.Log(c).Warn("Lost/found match lookup failed", "listing_id", item.ID, "error", err)
+		matches = []SimilarListing{}
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Entry created successfully", "data": input})
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Entry created successfully",
+		"data":       item,
+		"duplicates": duplicatesForPoster(c, models.ListingLostFound, item.ID),
+		"matches":    matches,
+	})
 }
 
 // Get all Lost & Found entries (optional filter by category)
@@ -44,95 +74,100 @@ func GetLostFound(c *gin.Context) {
 	var items []models.LostFound
 	category := c.Query("category")
 
-	query := config.DB.Order("created_at desc")
+	query := withoutBlocked(c, withImages(withOwner(db(c)).Preload("Image")), "owner_id").Order("created_at desc")
+	query, ok := mineOnly(c, query, "owner_id")
+	if !ok {
+		return
+	}
 
 	if category != "" {
 		category = strings.ToLower(category)
 		if category != models.CategoryLost && category != models.CategoryFound {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category filter"})
+			apierror.Abort(c, apierror.Field("category", "Invalid category filter"))
 			return
 		}
 		query = query.Where("category = ?", category)
 	}
 
-	query.Find(&items)
-	c.JSON(http.StatusOK, items)
+	if err := query.Find(&items).Error; err != nil {
+		serverError(c, "Failed to fetch lost/found entries", err)
+		return
+	}
+	respondWithContentETag(c, items)
+}
+
+// Get single Lost & Found entry
+func GetLostFoundByID(c *gin.Context) {
+	id := c.Param("id")
+
+	var item models.LostFound
+	if err := withImages(withOwner(db(c)).Preload("Image")).First(&item, "id = ?", id).Error; err != nil {
+		apierror.Abort(c, apierror.NotFound("Item not found"))
+		return
+	}
+	if !checkNotBlocked(c, item.OwnerID, "Item not found") {
+		return
+	}
+
+	respondWithETag(c, listingETag(item.ID, item.Version, item.Owner), item)
 }
 
 // Update Lost & Found entry (owner only)
 func UpdateLostFound(c *gin.Context) {
 	id := c.Param("id")
-	ownerID := c.Query("owner_id")
+	ownerID := middleware.UserID(c)
 
 	var item models.LostFound
-	if err := config.DB.First(&item, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+	if err := withImages(withOwner(db(c)).Preload("Image")).First(&item, "id = ?", id).Error; err != nil {
+		apierror.Abort(c, apierror.NotFound("Item not found"))
 		return
 	}
 
 	if item.OwnerID != ownerID {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized action"})
+		apierror.Abort(c, apierror.Forbidden("Unauthorized action"))
 		return
 	}
 
-	var input models.LostFound
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format"})
+	if !checkIfMatch(c, listingETag(item.ID, item.Version, item.Owner)) {
 		return
 	}
 
-	updateData := map[string]interface{}{}
-
-	if input.Title != "" {
-		updateData["title"] = input.Title
-	}
-	if input.Description != "" {
-		updateData["description"] = input.Description
-	}
-	if input.ImageURL != "" {
-		updateData["image_url"] = input.ImageURL
-	}
-	if input.Location != "" {
-		updateData["location"] = input.Location
-	}
-	if input.Phone != "" {
-		updateData["phone"] = input.Phone
-	}
-	if input.Category != "" {
-		input.Category = strings.ToLower(input.Category)
-		if input.Category != models.CategoryLost && input.Category != models.CategoryFound {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Category must be 'lost' or 'found'"})
-			return
-		}
-		updateData["category"] = input.Category
+	var input dto.LostFoundPatch
+	updates, ok := bindPatch(c, &input, dto.LostFoundPatchFields)
+	if !ok {
+		return
 	}
 
-	if len(updateData) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No valid fields to update"})
+	if !updateVersioned(c, &item, &item.Version, updates, "Failed to update item") {
 		return
 	}
 
-	config.DB.Model(&item).Updates(updateData)
-
+	c.Header("ETag", listingETag(item.ID, item.Version, item.Owner))
 	c.JSON(http.StatusOK, gin.H{"message": "Item updated successfully", "data": item})
 }
 
 // Delete Lost & Found entry (owner only)
 func DeleteLostFound(c *gin.Context) {
 	id := c.Param("id")
-	ownerID := c.Query("owner_id")
+	ownerID := middleware.UserID(c)
 
 	var item models.LostFound
-	if err := config.DB.First(&item, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+	if err := withImages(withOwner(db(c)).Preload("Image")).First(&item, "id = ?", id).Error; err != nil {
+		apierror.Abort(c, apierror.NotFound("Item not found"))
 		return
 	}
 
 	if item.OwnerID != ownerID {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized action"})
+		apierror.Abort(c, apierror.Forbidden("Unauthorized action"))
 		return
 	}
 
-	config.DB.Delete(&item)
+	if !checkIfMatch(c, listingETag(item.ID, item.Version, item.Owner)) {
+		return
+	}
+
+	if !deleteVersioned(c, &item, models.ListingLostFound, item.ID, item.Version, "Failed to delete item") {
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Item deleted successfully"})
 }
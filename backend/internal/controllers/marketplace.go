@@ -2,123 +2,153 @@ package controllers
 
 import (
 	"net/http"
-	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/shreyashsri79/vitbuddy-backend/internal/config"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/dto"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/imageproc"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/middleware"
 	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"gorm.io/gorm"
 )
 
-// Validate marketplace input before saving
-func validateMarketplaceInput(item *models.MarketplaceItem) string {
-	if strings.TrimSpace(item.OwnerID) == "" {
-		return "owner_id is required"
-	}
-	if strings.TrimSpace(item.Phone) == "" {
-		return "phone is required"
-	}
-	if len(item.Phone) < 10 || len(item.Phone) > 15 {
-		return "phone must be between 10-15 digits"
-	}
-	if item.Price < 0 {
-		return "price cannot be negative"
-	}
-	return ""
-}
-
 // ✅ Create marketplace item
 func CreateMarketplaceItem(c *gin.Context) {
-	var input models.MarketplaceItem
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+	var req dto.CreateMarketplaceItemRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	// Validate before DB write
-	if msg := validateMarketplaceInput(&input); msg != "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
-		return
+	item := req.Model(middleware.UserID(c))
+	var image *models.Asset
+	if item.ImageAssetID != nil {
+		var ok bool
+		if image, ok = findAsset(c, "image_asset_id", *item.ImageAssetID); !ok {
+			return
+		}
+		if !checkUploader(c, image, item.OwnerID) {
+			return
+		}
+		item.ImageURL = image.Variants[imageproc.VariantOriginal].URL
 	}
 
-	if err := config.DB.Create(&input).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create item"})
+	// The initial image becomes the first attachment, i.e. the cover
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&item).Error; err != nil {
+			return err
+		}
+		if image == nil {
+			return nil
+		}
+		return attachImage(tx, models.ListingMarketplace, item.ID, image, 0)
+	})
+	if !txError(c, err, "Failed to create item") {
 		return
 	}
+	item.Image = image
+	item.Images = []models.AssetAttachment{}
+	item.Owner = userSummary(c, item.OwnerID)
+	if image != nil {
+		item.Images = append(item.Images, models.AssetAttachment{AssetID: image.ID, Asset: image})
+	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Item created", "data": input})
+	// Warn the poster when the photo was already used on another item
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Item created",
+		"data":       item,
+		"duplicates": duplicatesForPoster(c, models.ListingMarketplace, item.ID),
+	})
 }
 
 // ✅ Get all marketplace items
 func GetMarketplaceItems(c *gin.Context) {
 	var items []models.MarketplaceItem
-	config.DB.Order("created_at desc").Find(&items)
-	c.JSON(http.StatusOK, items)
+	query := withoutBlocked(c, withImages(withOwner(db(c)).Preload("Image")), "owner_id").Order("created_at desc")
+	query, ok := mineOnly(c, query, "owner_id")
+	if !ok {
+		return
+	}
+	if err := query.Find(&items).Error; err != nil {
+		serverError(c, "Failed to fetch items", err)
+		return
+	}
+	respondWithContentETag(c, items)
+}
+
+// ✅ Get single marketplace item
+func GetMarketplaceItemByID(c *gin.Context) {
+	id := c.Param("id")
+
+	var item models.MarketplaceItem
+	if err := withImages(withOwner(db(c)).Preload("Image")).First(&item, "id = ?", id).Error; err != nil {
+		apierror.Abort(c, apierror.NotFound("Item not found"))
+		return
+	}
+	if !checkNotBlocked(c, item.OwnerID, "Item not found") {
+		return
+	}
+
+	respondWithETag(c, listingETag(item.ID, item.Version, item.Owner), item)
 }
 
 // ✅ Update marketplace item (owner only)
 func UpdateMarketplaceItem(c *gin.Context) {
 	id := c.Param("id")
-	ownerID := c.Query("owner_id")
+	ownerID := middleware.UserID(c)
 
 	var item models.MarketplaceItem
-	if err := config.DB.First(&item, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+	if err := withImages(withOwner(db(c)).Preload("Image")).First(&item, "id = ?", id).Error; err != nil {
+		apierror.Abort(c, apierror.NotFound("Item not found"))
 		return
 	}
 
 	// Ownership check
 	if item.OwnerID != ownerID {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not allowed to update"})
+		apierror.Abort(c, apierror.Forbidden("Not allowed to update"))
 		return
 	}
 
-	// Bind partial update
-	var input map[string]interface{}
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+	if !checkIfMatch(c, listingETag(item.ID, item.Version, item.Owner)) {
 		return
 	}
 
-	// Protect immutable fields
-	delete(input, "id")
-	delete(input, "owner_id")
-	delete(input, "created_at")
-
-	// Validate price if present
-	if price, ok := input["price"]; ok {
-		if price.(float64) < 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Price cannot be negative"})
-			return
-		}
+	// Only allowlisted fields, type checked; owner_id, id and timestamps are immutable
+	var input dto.MarketplaceItemPatch
+	updates, ok := bindPatch(c, &input, dto.MarketplaceItemPatchFields)
+	if !ok {
+		return
 	}
 
 	// Execute update
-	if err := config.DB.Model(&item).Updates(input).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update item"})
+	if !updateVersioned(c, &item, &item.Version, updates, "Failed to update item") {
 		return
 	}
 
+	c.Header("ETag", listingETag(item.ID, item.Version, item.Owner))
 	c.JSON(http.StatusOK, gin.H{"message": "Item updated", "data": item})
 }
 
 // ✅ Delete marketplace item (owner only)
 func DeleteMarketplaceItem(c *gin.Context) {
 	id := c.Param("id")
-	ownerID := c.Query("owner_id")
+	ownerID := middleware.UserID(c)
 
 	var item models.MarketplaceItem
-	if err := config.DB.First(&item, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+	if err := withImages(withOwner(db(c)).Preload("Image")).First(&item, "id = ?", id).Error; err != nil {
+		apierror.Abort(c, apierror.NotFound("Item not found"))
 		return
 	}
 
 	if item.OwnerID != ownerID {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not allowed to delete"})
+		apierror.Abort(c, apierror.Forbidden("Not allowed to delete"))
+		return
+	}
+
+	if !checkIfMatch(c, listingETag(item.ID, item.Version, item.Owner)) {
 		return
 	}
 
-	if err := config.DB.Delete(&item).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete item"})
+	if !deleteVersioned(c, &item, models.ListingMarketplace, item.ID, item.Version, "Failed to delete item") {
 		return
 	}
 
@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/dto"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/middleware"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+)
+
+// The visibility level the caller is entitled to on user's profile: private
+// for the user themselves and admins, campus for other verified users,
+// public for everyone else
+func profileAudience(c *gin.Context, user *models.User) (string, error) {
+	viewerID := middleware.UserID(c)
+	if viewerID == "" {
+		return models.VisibilityPublic, nil
+	}
+	if viewerID == user.ID {
+		return models.VisibilityPrivate, nil
+	}
+
+	var viewers []models.User
+	if err := db(c).Select("role", "verified").Where("id = ?", viewerID).Limit(1).Find(&viewers).Error; err != nil {
+		return "", err
+	}
+	switch {
+	case len(viewers) == 0:
+		return models.VisibilityPublic, nil
+	case viewers[0].Role == models.RoleAdmin:
+		return models.VisibilityPrivate, nil
+	case viewers[0].Verified:
+		return models.VisibilityCampus, nil
+	}
+	return models.VisibilityPublic, nil
+}
+
+// Checks the hostel, block, programme and year a patch leaves the user with
+// against the admin-managed lists. Only runs when one of them changes, so a
+// hostel removed from the list later does not block unrelated edits.
+func checkProfile(c *gin.Context, user models.User, input dto.UserPatch, updates map[string]any) bool {
+	changed := false
+	for key := range updates {
+		switch key {
+		case "hostel":
+			user.Hostel, changed = input.Hostel, true
+		case "block":
+			user.Block, changed = input.Block, true
+		case "programme":
+			user.Programme, changed = input.Programme, true
+		case "year":
+			user.Year, changed = input.Year, true
+		}
+	}
+	if !changed {
+		return true
+	}
+
+	var problems []apierror.FieldError
+	if user.Hostel == "" && user.Block != "" {
+		problems = append(problems, apierror.FieldError{Field: "block", Message: "block needs a hostel"})
+	}
+	if user.Hostel != "" {
+		var hostels []models.Hostel
+		if err := db(c).Where("code = ?", user.Hostel).Limit(1).Find(&hostels).Error; err != nil {
+			serverError(c, "Failed to load hostels", err)
+			return false
+		}
+		switch {
+		case len(hostels) == 0:
+			problems = append(problems, apierror.FieldError{Field: "hostel", Message: "Unknown hostel " + user.Hostel})
+		case user.Block != "" && !slices.Contains(hostels[0].Blocks, user.Block):
+			problems = append(problems, apierror.FieldError{Field: "block", Message: fmt.Sprintf("%s has no block %s", hostels[0].Name, user.Block)})
+		}
+	}
+	if user.Programme != "" {
+		var programmes []models.Programme
+		if err := db(c).Where("code = ?", user.Programme).Limit(1).Find(&programmes).Error; err != nil {
+			serverError(c, "Failed to load programmes", err)
+			return false
+		}
+		switch {
+		case len(programmes) == 0:
+			problems = append(problems, apierror.FieldError{Field: "programme", Message: "Unknown programme " + user.Programme})
+		case user.Year > programmes[0].Years:
+			problems = append(problems, apierror.FieldError{Field: "year", Message: fmt.Sprintf("year must be at most %d for %s", programmes[0].Years, programmes[0].Name)})
+		}
+	}
+
+	if len(problems) > 0 {
+		apierror.Abort(c, apierror.Validation("Invalid fields", problems...))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/config"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/middleware"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/patch"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/validation"
+	"gorm.io/gorm"
+)
+
+// Database handle bound to the request context so queries join the request trace
+func db(c *gin.Context) *gorm.DB {
+	return config.DB.WithContext(c.Request.Context())
+}
+
+// Log the real error against the request ID; the client only sees msg and the ID
+func serverError(c *gin.Context, msg string, err error) {
+	middleware.Log(c).Error(msg, "error", err)
+	apierror.Abort(c, apierror.Internal(msg))
+}
+
+// Responds to the error of a transaction: API errors returned from it are
+// client errors, anything else is a 500. Returns whether err was nil.
+func txError(c *gin.Context, err error, msg string) bool {
+	if err == nil {
+		return true
+	}
+	var apiErr *apierror.Error
+	if errors.As(err, &apiErr) {
+		apierror.Abort(c, apiErr)
+	} else {
+		serverError(c, msg, err)
+	}
+	return false
+}
+
+// Bind and validate a JSON body; on failure responds with every failing field and returns false
+func bindJSON(c *gin.Context, dst any) bool {
+	if err := c.ShouldBindJSON(dst); err != nil {
+		if fields, ok := validation.FieldErrors(err); ok {
+			apierror.Abort(c, apierror.Validation("Invalid fields", fields...))
+		} else {
+			apierror.Abort(c, apierror.InvalidJSON("Invalid JSON format"))
+		}
+		return false
+	}
+	return true
+}
+
+// Apply a JSON merge patch body to dst; on failure responds with the problem and returns false
+func bindPatch(c *gin.Context, dst any, allowed patch.Allowlist) (map[string]any, bool) {
+	body, err := c.GetRawData()
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidJSON("Cannot read request body"))
+		return nil, false
+	}
+
+	updates, err := patch.Apply(body, dst, allowed)
+	if err != nil {
+		var apiErr *apierror.Error
+		switch {
+		case errors.As(err, &apiErr):
+			apierror.Abort(c, apiErr)
+		case errors.Is(err, patch.ErrNotObject):
+			apierror.Abort(c, apierror.InvalidJSON(err.Error()))
+		default:
+			apierror.Abort(c, apierror.InvalidJSON("Invalid JSON format"))
+		}
+		return nil, false
+	}
+	return updates, true
+}
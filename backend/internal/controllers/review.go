@@ -0,0 +1,308 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/dto"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/middleware"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ✅ Record that a sale, delivery or shared ride with partner_id took place (owner only);
+// sales and deliveries wait for the partner to accept them
+func RecordInteraction(listingType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kind := listingKinds[listingType]
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			apierror.Abort(c, apierror.Field("id", "id must be a positive integer"))
+			return
+		}
+		var req dto.InteractionRequest
+		if !bindJSON(c, &req) {
+			return
+		}
+		userID := middleware.UserID(c)
+
+		var owners []string
+		if err := db(c).Model(kind.model()).Where("id = ?", id).Pluck(kind.ownerColumn, &owners).Error; err != nil {
+			serverError(c, "Failed to load listing", err)
+			return
+		}
+		if len(owners) == 0 {
+			apierror.Abort(c, apierror.NotFound("Listing not found"))
+			return
+		}
+		if owners[0] != userID {
+			apierror.Abort(c, apierror.Forbidden("Only the owner can record an interaction"))
+			return
+		}
+		if !checkPartner(c, listingType, uint(id), req.PartnerID) {
+			return
+		}
+
+		interaction := models.Interaction{ListingType: listingType, ListingID: uint(id), OwnerID: &userID, PartnerID: &req.PartnerID}
+		message := "Interaction recorded; waiting for the partner to accept it"
+		if listingType == models.ListingCab {
+			// checkPartner made sure they joined the ride
+			now := time.Now()
+			interaction.AcceptedAt = &now
+			message = "Interaction recorded"
+		}
+		res := db(c).Clauses(clause.OnConflict{DoNothing: true}).Omit(clause.Associations).Create(&interaction)
+		if res.Error != nil {
+			serverError(c, "Failed to record interaction", res.Error)
+			return
+		}
+		if res.RowsAffected == 0 {
+			apierror.Abort(c, apierror.Conflict("This interaction is already recorded"))
+			return
+		}
+		interaction.Owner = userSummary(c, userID)
+		interaction.Partner = userSummary(c, req.PartnerID)
+		interaction.Reviews = []models.Review{}
+
+		c.JSON(http.StatusCreated, gin.H{"message": message, "data": interaction})
+	}
+}
+
+// The partner must be someone else with a profile; on a cab they must have
+// joined the ride, and the ride must have happened
+func checkPartner(c *gin.Context, listingType string, id uint, partnerID string) bool {
+	if partnerID == middleware.UserID(c) {
+		apierror.Abort(c, apierror.Field("partner_id", "partner_id must be another user"))
+		return false
+	}
+	var users int64
+	if err := db(c).Model(&models.User{}).Where("id = ?", partnerID).Count(&users).Error; err != nil {
+		serverError(c, "Failed to load partner", err)
+		return false
+	}
+	if users == 0 {
+		apierror.Abort(c, apierror.Field("partner_id", "partner_id does not refer to a user"))
+		return false
+	}
+	blocked, err := blockedBetween(c, middleware.UserID(c), partnerID)
+	if err != nil {
+		serverError(c, "Failed to load partner", err)
+		return false
+	}
+	if blocked {
+		apierror.Abort(c, apierror.Forbidden("You cannot record an interaction with this user"))
+		return false
+	}
+	if listingType != models.ListingCab {
+		return true
+	}
+
+	var post models.Cab
+	if err := db(c).Select("date").First(&post, id).Error; err != nil {
+		serverError(c, "Failed to load ride", err)
+		return false
+	}
+	var joined int64
+	if err := db(c).Model(&models.CabPassenger{}).Where("cab_id = ? AND user_id = ?", id, partnerID).Count(&joined).Error; err != nil {
+		serverError(c, "Failed to load passengers", err)
+		return false
+	}
+	if joined == 0 {
+		apierror.Abort(c, apierror.Field("partner_id", "partner_id has not joined this ride"))
+		return false
+	}
+	if time.Now().Before(post.Date) {
+		apierror.Abort(c, apierror.Conflict("The ride has not happened yet"))
+		return false
+	}
+	return true
+}
+
+// ✅ List the caller's interactions, newest first, with the reviews of both sides
+func GetMyInteractions(c *gin.Context) {
+	userID := middleware.UserID(c)
+	interactions := []models.Interaction{}
+	err := db(c).Preload("Owner").Preload("Partner").
+		Preload("Reviews", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		Where("owner_id = ? OR partner_id = ?", userID, userID).
+		Order("created_at desc").Find(&interactions).Error
+	if err != nil {
+		serverError(c, "Failed to fetch interactions", err)
+		return
+	}
+	respondWithContentETag(c, interactions)
+}
+
+// Loads the interaction in the path
+func findInteraction(c *gin.Context) (*models.Interaction, bool) {
+	var interaction models.Interaction
+	err := db(c).First(&interaction, "id = ?", c.Param("id")).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Abort(c, apierror.NotFound("Interaction not found"))
+		return nil, false
+	}
+	if err != nil {
+		serverError(c, "Failed to load interaction", err)
+		return nil, false
+	}
+	return &interaction, true
+}
+
+// ✅ Accept an interaction the listing's owner recorded with the caller (partner only)
+func AcceptInteraction(c *gin.Context) {
+	interaction, ok := findInteraction(c)
+	if !ok {
+		return
+	}
+	userID := middleware.UserID(c)
+	if interaction.PartnerID == nil || *interaction.PartnerID != userID {
+		apierror.Abort(c, apierror.Forbidden("Only the partner can accept an interaction"))
+		return
+	}
+	if interaction.OwnerID == nil {
+		apierror.Abort(c, apierror.Conflict("The other user's account has been deleted"))
+		return
+	}
+	blocked, err := blockedBetween(c, *interaction.OwnerID, userID)
+	if err != nil {
+		serverError(c, "Failed to accept interaction", err)
+		return
+	}
+	if blocked {
+		apierror.Abort(c, apierror.Forbidden("You cannot accept an interaction with this user"))
+		return
+	}
+
+	now := time.Now()
+	res := db(c).Model(&models.Interaction{}).Where("id = ? AND accepted_at IS NULL", interaction.ID).
+		Update("accepted_at", now)
+	if res.Error != nil {
+		serverError(c, "Failed to accept interaction", res.Error)
+		return
+	}
+	if res.RowsAffected == 0 {
+		apierror.Abort(c, apierror.Conflict("This interaction is already accepted"))
+		return
+	}
+	interaction.AcceptedAt = &now
+	interaction.Owner = userSummary(c, *interaction.OwnerID)
+	interaction.Partner = userSummary(c, userID)
+	interaction.Reviews = []models.Review{}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Interaction accepted", "data": interaction})
+}
+
+// ✅ Decline (partner) or withdraw (owner) an interaction that is not accepted yet
+func DeleteInteraction(c *gin.Context) {
+	interaction, ok := findInteraction(c)
+	if !ok {
+		return
+	}
+	userID := middleware.UserID(c)
+	is := func(id *string) bool { return id != nil && *id == userID }
+	if !is(interaction.OwnerID) && !is(interaction.PartnerID) {
+		apierror.Abort(c, apierror.Forbidden("You did not take part in this interaction"))
+		return
+	}
+
+	res := db(c).Where("id = ? AND accepted_at IS NULL", interaction.ID).Delete(&models.Interaction{})
+	if res.Error != nil {
+		serverError(c, "Failed to delete interaction", res.Error)
+		return
+	}
+	if res.RowsAffected == 0 {
+		apierror.Abort(c, apierror.Conflict("An accepted interaction cannot be deleted"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Interaction deleted"})
+}
+
+// ✅ Rate the other side of an accepted interaction the caller took part in; once per side
+func CreateReview(c *gin.Context) {
+	var req dto.ReviewRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	userID := middleware.UserID(c)
+
+	interaction, ok := findInteraction(c)
+	if !ok {
+		return
+	}
+
+	is := func(id *string) bool { return id != nil && *id == userID }
+	var reviewee *string
+	switch {
+	case is(interaction.OwnerID):
+		reviewee = interaction.PartnerID
+	case is(interaction.PartnerID):
+		reviewee = interaction.OwnerID
+	default:
+		apierror.Abort(c, apierror.Forbidden("You did not take part in this interaction"))
+		return
+	}
+	if reviewee == nil {
+		apierror.Abort(c, apierror.Conflict("The other user's account has been deleted"))
+		return
+	}
+	if interaction.AcceptedAt == nil {
+		apierror.Abort(c, apierror.Conflict("The partner has not accepted this interaction yet"))
+		return
+	}
+
+	review := models.Review{
+		InteractionID: interaction.ID,
+		ReviewerID:    &userID,
+		RevieweeID:    *reviewee,
+		Rating:        req.Rating,
+		Comment:       req.Comment,
+	}
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Omit(clause.Associations).Create(&review)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return apierror.Conflict("You already reviewed this interaction")
+		}
+		// The right-hand sides see the old count and sum
+		return tx.Model(&models.User{}).Where("id = ?", review.RevieweeID).Updates(map[string]any{
+			"rating_count":   gorm.Expr("rating_count + 1"),
+			"rating_sum":     gorm.Expr("rating_sum + ?", review.Rating),
+			"rating_average": gorm.Expr("(rating_sum + ?) * 1.0 / (rating_count + 1)", review.Rating),
+		}).Error
+	})
+	if !txError(c, err, "Failed to post review") {
+		return
+	}
+	review.Reviewer = userSummary(c, userID)
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Review posted", "data": review})
+}
+
+// ✅ List the reviews a user received, newest first
+func GetUserReviews(c *gin.Context) {
+	var users int64
+	if err := db(c).Model(&models.User{}).Where("id = ?", c.Param("id")).Count(&users).Error; err != nil {
+		serverError(c, "Failed to fetch reviews", err)
+		return
+	}
+	if users == 0 {
+		apierror.Abort(c, apierror.NotFound("User not found"))
+		return
+	}
+
+	reviews := []models.Review{}
+	err := db(c).Preload("Reviewer").Where("reviewee_id = ?", c.Param("id")).
+		Order("created_at desc").Find(&reviews).Error
+	if err != nil {
+		serverError(c, "Failed to fetch reviews", err)
+		return
+	}
+	respondWithContentETag(c, reviews)
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/config"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/middleware"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// The daily upload quota is a rolling window
+const quotaWindow = 24 * time.Hour
+
+// Rolls back an upload checkQuota already answered
+var errQuotaExceeded = errors.New("upload quota exceeded")
+
+type storageUsage struct {
+	Role         string             `json:"role"`
+	Quota        config.UploadQuota `json:"quota"`
+	UploadsToday int                `json:"uploads_today"`
+	Bytes        int64              `json:"bytes"`
+	Assets       int64              `json:"assets"`
+	// When the oldest of today's uploads stops counting; null without uploads
+	NextUploadFreesAt *time.Time `json:"next_upload_frees_at"`
+}
+
+// Users without a row yet (signed up but not synced) get the default role
+func userRole(tx *gorm.DB, userID string) (string, error) {
+	var roles []string
+	if err := tx.Model(&models.User{}).Where("id = ?", userID).Pluck("role", &roles).Error; err != nil {
+		return "", err
+	}
+	if len(roles) == 0 || roles[0] == "" {
+		return models.RoleUser, nil
+	}
+	return roles[0], nil
+}
+
+func loadStorageUsage(tx *gorm.DB, userID string) (*storageUsage, error) {
+	role, err := userRole(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	usage := &storageUsage{Role: role, Quota: config.QuotaFor(role)}
+
+	var totals struct {
+		Assets int64
+		Bytes  int64
+	}
+	err = tx.Model(&models.Asset{}).Where("uploader_id = ?", userID).
+		Select("COUNT(*) AS assets, COALESCE(SUM(bytes), 0) AS bytes").Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	usage.Assets, usage.Bytes = totals.Assets, totals.Bytes
+
+	// Counts only the uploads still stored. The asset collector deletes
+	// uploads left unattached for ASSET_GC_GRACE, so with a grace shorter
+	// than quotaWindow a user can let uploads be collected and upload more
+	// within the same day. Purge only clears uploader_id, so a purged
+	// account's uploads stop counting along with the account.
+	var recent []time.Time
+	err = tx.Model(&models.Asset{}).Where("uploader_id = ? AND created_at > ?", userID, time.Now().Add(-quotaWindow)).
+		Order("created_at").Pluck("created_at", &recent).Error
+	if err != nil {
+		return nil, err
+	}
+	usage.UploadsToday = len(recent)
+	if len(recent) > 0 {
+		frees := recent[0].Add(quotaWindow)
+		usage.NextUploadFreesAt = &frees
+	}
+	return usage, nil
+}
+
+// Rejects an upload of size bytes that would go over the caller's quota:
+// 429 with Retry-After for the daily count, 413 for stored bytes
+func checkQuota(c *gin.Context, usage *storageUsage, size int64) bool {
+	q := usage.Quota
+	if q.Daily > 0 && usage.UploadsToday >= q.Daily {
+		retry := 1
+		if usage.NextUploadFreesAt != nil {
+			retry = max(1, int(math.Ceil(time.Until(*usage.NextUploadFreesAt).Seconds())))
+		}
+		c.Header("Retry-After", strconv.Itoa(retry))
+		apierror.Abort(c, apierror.New(http.StatusTooManyRequests, apierror.CodeUploadQuota,
+			fmt.Sprintf("Daily upload limit of %d reached, retry in %d seconds", q.Daily, retry)))
+		return false
+	}
+	if q.Bytes > 0 && usage.Bytes+size > q.Bytes {
+		apierror.Abort(c, apierror.New(http.StatusRequestEntityTooLarge, apierror.CodeStorageQuota,
+			fmt.Sprintf("Storage quota exceeded: %d of %d bytes used, this upload needs %d", usage.Bytes, q.Bytes, size)))
+		return false
+	}
+	return true
+}
+
+// GetMyStorage reports the caller's upload usage against their quota
+func GetMyStorage(c *gin.Context) {
+	usage, err := loadStorageUsage(db(c), middleware.UserID(c))
+	if err != nil {
+		serverError(c, "Failed to load storage usage", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": usage})
+}
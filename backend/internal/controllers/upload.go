@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/config"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/imageproc"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/middleware"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/storage"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Generic upload endpoint for images; the signed-in uploader is recorded so only they can attach it
+func UploadImage(c *gin.Context) {
+	userID := middleware.UserID(c)
+
+	// Fail fast when the caller is already over quota, before reading the file
+	usage, err := loadStorageUsage(db(c), userID)
+	if err != nil {
+		serverError(c, "Failed to load storage usage", err)
+		return
+	}
+	if !checkQuota(c, usage, 0) {
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		apierror.Abort(c, apierror.Field("file", "No file is received"))
+		return
+	}
+
+	limits := config.UploadLimits
+	if file.Size > limits.MaxBytes {
+		abortUpload(c, fmt.Errorf("%w: limit is %d bytes", imageproc.ErrTooLarge, limits.MaxBytes))
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		serverError(c, "Cannot open file", err)
+		return
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, limits.MaxBytes+1))
+	if err != nil {
+		serverError(c, "Cannot read file", err)
+		return
+	}
+
+	// Type comes from the content, never the file name; re-encoding strips EXIF/GPS
+	variants, err := imageproc.Process(data, limits)
+	if err != nil {
+		abortUpload(c, err)
+		return
+	}
+
+	var size int64
+	for _, v := range variants {
+		size += int64(len(v.Data))
+	}
+	if !checkQuota(c, usage, size) {
+		return
+	}
+
+	original := variants[0]
+	hash := int64(original.Hash)
+	asset := models.Asset{
+		UploaderID: userID,
+		Bytes:      size,
+		PHash:      &hash,
+		Width:      original.Width,
+		Height:     original.Height,
+		Variants:   map[string]models.AssetVariant{},
+	}
+	for _, v := range variants {
+		stored, err := putVariant(c, v)
+		if err != nil {
+			deleteVariants(c, asset.Variants)
+			serverError(c, "Upload failed", err)
+			return
+		}
+		asset.Variants[v.Name] = stored
+	}
+	// Small images are not upscaled: those variants reuse the next larger one
+	for _, name := range []string{imageproc.VariantMedium, imageproc.VariantThumb} {
+		if _, ok := asset.Variants[name]; !ok {
+			asset.Variants[name] = asset.Variants[largerVariant(name)]
+		}
+	}
+
+	// Checked again with the uploader's row locked, so concurrent uploads by
+	// the same user are counted one after another instead of all passing
+	err = db(c).Transaction(func(tx *gorm.DB) error {
+		var uploader models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&uploader, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		usage, err := loadStorageUsage(tx, userID)
+		if err != nil {
+			return err
+		}
+		if !checkQuota(c, usage, size) {
+			return errQuotaExceeded
+		}
+		return tx.Create(&asset).Error
+	})
+	if err != nil {
+		deleteVariants(c, asset.Variants)
+		if !errors.Is(err, errQuotaExceeded) {
+			serverError(c, "Failed to save image", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Upload successful",
+		"imageURL": asset.Variants[imageproc.VariantOriginal].URL,
+		"asset":    asset,
+	})
+}
+
+func largerVariant(name string) string {
+	if name == imageproc.VariantThumb {
+		return imageproc.VariantMedium
+	}
+	return imageproc.VariantOriginal
+}
+
+func putVariant(c *gin.Context, v imageproc.Variant) (models.AssetVariant, error) {
+	key := storage.NewKey(v.Ext)
+
+	ctx, span := config.Tracer.Start(c.Request.Context(), "storage.put",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("storage.driver", config.Storage.Driver()),
+			attribute.String("storage.key", key),
+			attribute.String("upload.variant", v.Name),
+			attribute.Int64("upload.size", int64(len(v.Data))),
+		),
+	)
+	defer span.End()
+
+	url, err := config.Storage.Put(ctx, key, bytes.NewReader(v.Data), int64(len(v.Data)), v.ContentType)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "upload failed")
+		return models.AssetVariant{}, err
+	}
+	return models.AssetVariant{
+		Key:         key,
+		URL:         url,
+		ContentType: v.ContentType,
+		Width:       v.Width,
+		Height:      v.Height,
+		Bytes:       len(v.Data),
+	}, nil
+}
+
+// Best-effort cleanup of variants already stored when a later step fails
+func deleteVariants(c *gin.Context, variants map[string]models.AssetVariant) {
+	deleted := map[string]bool{}
+	for _, v := range variants {
+		if deleted[v.Key] {
+			continue
+		}
+		deleted[v.Key] = true
+		if err := config.Storage.Delete(c.Request.Context(), v.Key); err != nil {
+			middleware.Log(c).Warn("Failed to delete stored variant", "key", v.Key, "error", err)
+		}
+	}
+}
+
+// Maps imageproc rejections to 413, 415 or a field error on "file"
+func abortUpload(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, imageproc.ErrTooLarge):
+		apierror.Abort(c, apierror.New(http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, err.Error()))
+	case errors.Is(err, imageproc.ErrUnsupported):
+		apierror.Abort(c, apierror.New(http.StatusUnsupportedMediaType, apierror.CodeUnsupportedMedia, err.Error()))
+	case errors.Is(err, imageproc.ErrDimensions), errors.Is(err, imageproc.ErrCorrupt):
+		apierror.Abort(c, apierror.Field("file", err.Error()))
+	default:
+		serverError(c, "Failed to process image", err)
+	}
+}
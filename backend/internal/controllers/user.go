@@ -2,51 +2,40 @@ package controllers
 
 import (
 	"net/http"
-	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/shreyashsri79/vitbuddy-backend/internal/config"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/dto"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/middleware"
 	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
 )
 
-// Validate user input
-func validateUserInput(user *models.User) string {
-	if strings.TrimSpace(user.Email) == "" || !strings.Contains(user.Email, "@") {
-		return "Valid email is required"
-	}
-	if strings.TrimSpace(user.Username) == "" {
-		return "Username is required"
-	}
-	return ""
-}
-
 // ✅ Create user
 func CreateUser(c *gin.Context) {
-	var input models.User
-
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+	var req dto.CreateUserRequest
+	if !bindJSON(c, &req) {
 		return
 	}
-
-	// Input validation
-	if msg := validateUserInput(&input); msg != "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+	input := req.Model()
+	if input.ID != middleware.UserID(c) {
+		apierror.Abort(c, apierror.Forbidden("You can only create your own profile"))
 		return
 	}
 
 	// Prevent duplicate users
 	var existing models.User
-	if err := config.DB.Where("email = ? OR username = ?", input.Email, input.Username).First(&existing).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Email or username already taken"})
+	if err := db(c).Where("email = ? OR username = ?", input.Email, input.Username).First(&existing).Error; err == nil {
+		apierror.Abort(c, apierror.Conflict("Email or username already taken"))
 		return
 	}
 
-	if err := config.DB.Create(&input).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+	if err := db(c).Create(&input).Error; err != nil {
+		serverError(c, "Failed to create user", err)
 		return
 	}
 
+	input.Privacy = input.EffectivePrivacy()
 	c.JSON(http.StatusCreated, gin.H{"message": "User created", "data": input})
 }
 
@@ -55,59 +44,121 @@ func GetUserByID(c *gin.Context) {
 	id := c.Param("id")
 	var user models.User
 
-	if err := config.DB.First(&user, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	if err := db(c).First(&user, "id = ?", id).Error; err != nil {
+		apierror.Abort(c, apierror.NotFound("User not found"))
+		return
+	}
+
+	// Profile fields are only shown to whom the user allows
+	audience, err := profileAudience(c, &user)
+	if err != nil {
+		serverError(c, "Failed to fetch user", err)
 		return
 	}
+	if user.ID == middleware.UserID(c) {
+		user.Privacy = user.EffectivePrivacy()
+	} else {
+		user.HideFrom(audience)
+	}
+	c.Header("Vary", "Authorization")
+	respondWithContentETag(c, user)
+}
 
-	c.JSON(http.StatusOK, user)
+// ✅ Get the caller's own user, with the whole profile and its privacy settings
+func GetMe(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	user.Privacy = user.EffectivePrivacy()
+	c.Header("Vary", "Authorization")
+	respondWithContentETag(c, user)
 }
 
 // ✅ Update user
 func UpdateUser(c *gin.Context) {
 	id := c.Param("id")
+	if id != middleware.UserID(c) {
+		apierror.Abort(c, apierror.Forbidden("You can only change your own profile"))
+		return
+	}
+	updateUser(c, id)
+}
+
+// ✅ Update the caller's own user
+func UpdateMe(c *gin.Context) {
+	updateUser(c, middleware.UserID(c))
+}
+
+func updateUser(c *gin.Context, id string) {
 	var user models.User
 
 	// Find existing user
-	if err := config.DB.First(&user, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	if err := db(c).First(&user, "id = ?", id).Error; err != nil {
+		apierror.Abort(c, apierror.NotFound("User not found"))
+		return
+	}
+
+	// Only email, username, avatar_url and the profile can change
+	var input dto.UserPatch
+	updates, ok := bindPatch(c, &input, dto.UserPatchFields)
+	if !ok {
 		return
 	}
 
-	// Bind json safely into map
-	var input map[string]interface{}
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+	if _, ok := updates["privacy"]; ok {
+		privacy := user.EffectivePrivacy()
+		for field, visibility := range input.Privacy {
+			privacy[field] = visibility
+		}
+		updates["privacy"] = privacy
+	}
+	if !checkProfile(c, user, input, updates) {
 		return
 	}
 
-	// Block sensitive fields from being modified
-	delete(input, "id")
-	delete(input, "created_at")
+	// Giving a gender attests it, and a changed one has to be reviewed again.
+	// A rejection stays until an admin lifts it.
+	if _, ok := updates["gender"]; ok && input.Gender != user.Gender {
+		updates["gender_attested_at"] = nil
+		if input.Gender != "" {
+			updates["gender_attested_at"] = time.Now()
+		}
+		if user.GenderReview != models.GenderRejected {
+			updates["gender_review"] = ""
+			updates["gender_reviewed_by"] = ""
+			updates["gender_reviewed_at"] = nil
+		}
+	}
 
 	// If email is being updated, check duplicates
-	if newEmail, ok := input["email"]; ok {
+	if _, ok := updates["email"]; ok && input.Email != user.Email {
+		// A new address has to be verified again
+		updates["verified"] = false
+		updates["verified_at"] = nil
+
 		var check models.User
-		if err := config.DB.Where("email = ?", newEmail).First(&check).Error; err == nil && check.ID != id {
-			c.JSON(http.StatusConflict, gin.H{"error": "Email already in use"})
+		if err := db(c).Where("email = ?", input.Email).First(&check).Error; err == nil && check.ID != id {
+			apierror.Abort(c, apierror.Conflict("Email already in use", apierror.FieldError{Field: "email", Message: "Email already in use"}))
 			return
 		}
 	}
 
 	// If username is being updated, check duplicates
-	if newUsername, ok := input["username"]; ok {
+	if _, ok := updates["username"]; ok {
 		var check models.User
-		if err := config.DB.Where("username = ?", newUsername).First(&check).Error; err == nil && check.ID != id {
-			c.JSON(http.StatusConflict, gin.H{"error": "Username already in use"})
+		if err := db(c).Where("username = ?", input.Username).First(&check).Error; err == nil && check.ID != id {
+			apierror.Abort(c, apierror.Conflict("Username already in use", apierror.FieldError{Field: "username", Message: "Username already in use"}))
 			return
 		}
 	}
 
 	// Update in DB
-	if err := config.DB.Model(&user).Updates(input).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+	if err := db(c).Model(&user).Updates(updates).Error; err != nil {
+		serverError(c, "Failed to update user", err)
 		return
 	}
 
+	user.Privacy = user.EffectivePrivacy()
 	c.JSON(http.StatusOK, gin.H{"message": "User updated", "data": user})
 }
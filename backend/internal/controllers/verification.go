@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/config"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/dto"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/mailer"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/middleware"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/validation"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// A new code can be requested this long after the previous one
+	resendCooldown = time.Minute
+	// Codes checked per user per attemptWindow, across resends; a six-digit
+	// code cannot be brute-forced. Verification is locked once they are used.
+	maxCodeAttempts = 10
+	attemptWindow   = 24 * time.Hour
+)
+
+// Locked reports when a user who used up their attempts may try again
+func attemptsLocked(v models.EmailVerification, now time.Time) (time.Time, bool) {
+	until := v.AttemptsSince.Add(attemptWindow)
+	return until, v.UserID != "" && v.Attempts >= maxCodeAttempts && now.Before(until)
+}
+
+func abortLocked(c *gin.Context, until time.Time) {
+	seconds := int(time.Until(until).Seconds()) + 1
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	apierror.Abort(c, apierror.New(http.StatusTooManyRequests, apierror.CodeRateLimited,
+		fmt.Sprintf("Too many wrong codes, retry in %d minutes", (seconds+59)/60)))
+}
+
+// Loads the signed-in user's row; responds 404 when they have no profile yet
+func currentUser(c *gin.Context) (*models.User, bool) {
+	var user models.User
+	err := db(c).First(&user, "id = ?", middleware.UserID(c)).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Abort(c, apierror.NotFound("Create your profile first"))
+		return nil, false
+	}
+	if err != nil {
+		serverError(c, "Failed to load user", err)
+		return nil, false
+	}
+	return &user, true
+}
+
+func newCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// Salted with the user id so equal codes of different users hash differently
+func hashCode(userID, code string) string {
+	sum := sha256.Sum256([]byte(userID + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
+
+// SendVerificationEmail mails a one-time code to the caller's campus address
+func SendVerificationEmail(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if user.Verified {
+		c.JSON(http.StatusOK, gin.H{"message": "Email already verified", "data": user})
+		return
+	}
+	if !validation.IsCampusEmail(user.Email) {
+		apierror.Abort(c, apierror.Field("email",
+			"Only campus addresses can be verified ("+strings.Join(validation.CampusDomains, ", ")+"); change your email first"))
+		return
+	}
+
+	var pending models.EmailVerification
+	err := db(c).Where("user_id = ?", user.ID).Limit(1).Find(&pending).Error
+	if err != nil {
+		serverError(c, "Failed to load verification", err)
+		return
+	}
+	if until, locked := attemptsLocked(pending, time.Now()); locked {
+		abortLocked(c, until)
+		return
+	}
+	if wait := time.Until(pending.SentAt.Add(resendCooldown)); pending.UserID != "" && wait > 0 {
+		seconds := int(wait.Seconds()) + 1
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		apierror.Abort(c, apierror.New(http.StatusTooManyRequests, apierror.CodeRateLimited,
+			fmt.Sprintf("A code was just sent, retry in %d seconds", seconds)))
+		return
+	}
+
+	code, err := newCode()
+	if err != nil {
+		serverError(c, "Failed to generate code", err)
+		return
+	}
+	now := time.Now()
+	record := models.EmailVerification{
+		UserID:        user.ID,
+		Email:         user.Email,
+		CodeHash:      hashCode(user.ID, code),
+		AttemptsSince: now,
+		SentAt:        now,
+		ExpiresAt:     now.Add(config.EmailCodeTTL),
+	}
+	// Replaces the code but keeps the attempts until their window has passed
+	cutoff := now.Add(-attemptWindow)
+	err = db(c).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"email":          record.Email,
+			"code_hash":      record.CodeHash,
+			"sent_at":        record.SentAt,
+			"expires_at":     record.ExpiresAt,
+			"attempts":       gorm.Expr("CASE WHEN attempts_since < ? THEN 0 ELSE attempts END", cutoff),
+			"attempts_since": gorm.Expr("CASE WHEN attempts_since < ? THEN ? ELSE attempts_since END", cutoff, now),
+		}),
+	}).Create(&record).Error
+	if err != nil {
+		serverError(c, "Failed to save verification code", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+	err = config.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your VIT Buddy verification code",
+		Text: fmt.Sprintf("Your VIT Buddy verification code is %s.\n\nIt expires in %d minutes. "+
+			"If you did not sign up for VIT Buddy, ignore this email.\n", code, int(config.EmailCodeTTL.Minutes())),
+	})
+	if err != nil {
+		// Let the user retry right away instead of waiting out the cooldown
+		db(c).Model(&record).Update("sent_at", pending.SentAt)
+		serverError(c, "Failed to send verification email", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Verification code sent", "expires_at": record.ExpiresAt})
+}
+
+// ConfirmEmail checks the code and marks the caller verified
+func ConfirmEmail(c *gin.Context) {
+	var req dto.ConfirmEmailRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if user.Verified {
+		c.JSON(http.StatusOK, gin.H{"message": "Email already verified", "data": user})
+		return
+	}
+
+	var pending models.EmailVerification
+	if err := db(c).Where("user_id = ?", user.ID).Limit(1).Find(&pending).Error; err != nil {
+		serverError(c, "Failed to load verification", err)
+		return
+	}
+	switch {
+	case pending.UserID == "" || pending.Email != user.Email:
+		apierror.Abort(c, apierror.Field("code", "No code is pending for your email, request a new one"))
+		return
+	case time.Now().After(pending.ExpiresAt):
+		apierror.Abort(c, apierror.Field("code", "The code has expired, request a new one"))
+		return
+	}
+
+	// Counted before comparing, atomically, so parallel guesses cannot exceed the limit
+	now := time.Now()
+	cutoff := now.Add(-attemptWindow)
+	res := db(c).Model(&pending).Where("attempts < ? OR attempts_since < ?", maxCodeAttempts, cutoff).
+		Updates(map[string]any{
+			"attempts":       gorm.Expr("CASE WHEN attempts_since < ? THEN 1 ELSE attempts + 1 END", cutoff),
+			"attempts_since": gorm.Expr("CASE WHEN attempts_since < ? THEN ? ELSE attempts_since END", cutoff, now),
+		})
+	if res.Error != nil {
+		serverError(c, "Failed to record attempt", res.Error)
+		return
+	}
+	if res.RowsAffected == 0 {
+		until, _ := attemptsLocked(pending, now)
+		abortLocked(c, until)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(hashCode(user.ID, req.Code)), []byte(pending.CodeHash)) != 1 {
+		apierror.Abort(c, apierror.Field("code", "Wrong code"))
+		return
+	}
+
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]any{"verified": true, "verified_at": now}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&pending).Error
+	})
+	if err != nil {
+		serverError(c, "Failed to verify email", err)
+		return
+	}
+	user.Verified, user.VerifiedAt = true, &now
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified", "data": user})
+}
@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/accounts"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/config"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/middleware"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	eventUserCreated = "user.created"
+	eventUserUpdated = "user.updated"
+	eventUserDeleted = "user.deleted"
+)
+
+type clerkEvent struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// The parts of Clerk's user object we keep
+type clerkUser struct {
+	ID                    string  `json:"id"`
+	Username              *string `json:"username"`
+	ImageURL              string  `json:"image_url"`
+	PrimaryEmailAddressID string  `json:"primary_email_address_id"`
+	EmailAddresses        []struct {
+		ID           string `json:"id"`
+		EmailAddress string `json:"email_address"`
+	} `json:"email_addresses"`
+	UpdatedAt int64 `json:"updated_at"` // ms
+}
+
+func (u clerkUser) primaryEmail() string {
+	for _, e := range u.EmailAddresses {
+		if e.ID == u.PrimaryEmailAddressID {
+			return e.EmailAddress
+		}
+	}
+	if len(u.EmailAddresses) > 0 {
+		return u.EmailAddresses[0].EmailAddress
+	}
+	return ""
+}
+
+// ClerkWebhook keeps users in sync with Clerk. Deliveries are verified with
+// the Svix signature and applied once per svix-id, so retries are harmless.
+func ClerkWebhook(c *gin.Context) {
+	if config.ClerkWebhook == nil {
+		apierror.Abort(c, apierror.New(http.StatusServiceUnavailable, apierror.CodeInternal, "Clerk webhooks are not configured"))
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidJSON("Cannot read request body"))
+		return
+	}
+	if err := config.ClerkWebhook.Verify(c.Request.Header, body, time.Now()); err != nil {
+		middleware.Log(c).Warn("Rejected Clerk webhook", "error", err)
+		apierror.Abort(c, apierror.Unauthorized("Invalid webhook signature"))
+		return
+	}
+
+	var event clerkEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		apierror.Abort(c, apierror.InvalidJSON("Invalid event payload"))
+		return
+	}
+	var user clerkUser
+	if err := json.Unmarshal(event.Data, &user); err != nil || user.ID == "" {
+		apierror.Abort(c, apierror.Field("data.id", "Event has no user id"))
+		return
+	}
+
+	msgID := c.GetHeader("svix-id")
+	outcome := "processed"
+	err = db(c).Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.WebhookEvent{ID: msgID, Type: event.Type, ObjectID: user.ID})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			outcome = "duplicate"
+			return nil
+		}
+
+		switch event.Type {
+		case eventUserCreated, eventUserUpdated:
+			var err error
+			outcome, err = syncClerkUser(tx, user)
+			return err
+		case eventUserDeleted:
+			return accounts.Purge(tx, user.ID)
+		default:
+			outcome = "ignored"
+			return nil
+		}
+	})
+	if !txError(c, err, "Failed to apply Clerk webhook") {
+		return
+	}
+
+	middleware.Log(c).Info("Clerk webhook", "svix_id", msgID, "type", event.Type, "user_id", user.ID, "outcome", outcome)
+	c.JSON(http.StatusOK, gin.H{"message": "Event " + outcome})
+}
+
+// Creates or updates the user from Clerk's copy. Clerk's username and image
+// are applied on every delivery; the email only fills new rows and orphan
+// placeholders, since afterwards it is the campus address the user verified
+// here. Deliveries older than what is stored, or for a deleted user, are
+// "stale"; users without an email cannot be stored and are "skipped".
+func syncClerkUser(tx *gorm.DB, u clerkUser) (string, error) {
+	var deleted int64
+	err := tx.Model(&models.WebhookEvent{}).
+		Where("type = ? AND object_id = ?", eventUserDeleted, u.ID).Count(&deleted).Error
+	if err != nil {
+		return "", err
+	}
+	if deleted > 0 {
+		return "stale", nil
+	}
+
+	var stored []models.User
+	if err := tx.Select("email", "clerk_updated_at").Where("id = ?", u.ID).Limit(1).Find(&stored).Error; err != nil {
+		return "", err
+	}
+	placeholder := len(stored) > 0 && strings.HasSuffix(stored[0].Email, "@"+accounts.OrphanEmailDomain)
+	if len(stored) > 0 && stored[0].ClerkUpdatedAt > u.UpdatedAt {
+		return "stale", nil
+	}
+
+	if len(stored) > 0 && !placeholder {
+		updates := map[string]any{"avatar_url": u.ImageURL, "clerk_updated_at": u.UpdatedAt}
+		// Without a username in Clerk the one chosen here stays
+		if u.Username != nil && *u.Username != "" {
+			if err := checkClerkConflict(tx, u.ID, "", *u.Username); err != nil {
+				return "", err
+			}
+			updates["username"] = *u.Username
+		}
+		err := tx.Model(&models.User{}).Where("id = ?", u.ID).Updates(updates).Error
+		return "processed", err
+	}
+
+	email := u.primaryEmail()
+	if email == "" {
+		return "skipped", nil
+	}
+	// Usernames are optional in Clerk but unique here; the id is a safe stand-in
+	username := u.ID
+	if u.Username != nil && *u.Username != "" {
+		username = *u.Username
+	}
+	if err := checkClerkConflict(tx, u.ID, email, username); err != nil {
+		return "", err
+	}
+
+	user := models.User{
+		ID:             u.ID,
+		Email:          email,
+		Username:       username,
+		AvatarURL:      u.ImageURL,
+		ClerkUpdatedAt: u.UpdatedAt,
+	}
+	if placeholder {
+		err = tx.Model(&user).Select("email", "username", "avatar_url", "clerk_updated_at").Updates(&user).Error
+	} else {
+		err = tx.Create(&user).Error
+	}
+	if err != nil {
+		return "", err
+	}
+	return "processed", nil
+}
+
+// Another account holding the email (when given) or username is a conflict.
+// Nothing is changed and Svix retries, by when the other account's own
+// update may have freed it.
+func checkClerkConflict(tx *gorm.DB, id, email, username string) error {
+	q := tx.Model(&models.User{}).Where("id <> ?", id)
+	if email != "" {
+		q = q.Where("(email = ? OR username = ?)", email, username)
+	} else {
+		q = q.Where("username = ?", username)
+	}
+	var taken int64
+	if err := q.Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return apierror.Conflict("Email or username is used by another account")
+	}
+	return nil
+}
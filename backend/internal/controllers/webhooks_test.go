@@ -0,0 +1,373 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/accounts"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/config"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/webhook"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testWebhookSecret = "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"
+
+// A router serving ClerkWebhook on a fresh in-memory database, and the
+// verifier whose Sign makes deliveries it accepts
+func clerkWebhookServer(t *testing.T) (*gin.Engine, *gorm.DB, *webhook.Svix) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	db, err := gorm.Open(sqlite.Open("file::memory:?_foreign_keys=1"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	// Every connection to :memory: is a database of its own
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&models.User{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	err = db.AutoMigrate(
+		&models.Asset{}, &models.AssetAttachment{},
+		&models.LostFound{}, &models.MarketplaceItem{}, &models.Delibuddy{}, &models.Cab{}, &models.CabPassenger{},
+		&models.WebhookEvent{}, &models.EmailVerification{},
+		&models.Interaction{}, &models.Review{}, &models.Block{},
+	)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	svix, err := webhook.NewSvix(testWebhookSecret)
+	if err != nil {
+		t.Fatalf("NewSvix: %v", err)
+	}
+	prevDB, prevHook := config.DB, config.ClerkWebhook
+	config.DB, config.ClerkWebhook = db, svix
+	t.Cleanup(func() { config.DB, config.ClerkWebhook = prevDB, prevHook })
+
+	r := gin.New()
+	r.POST("/webhooks/clerk", ClerkWebhook)
+	return r, db, svix
+}
+
+// A Clerk user event; updatedAt is Clerk's updated_at in ms
+func clerkUserEvent(eventType, userID, email, username string, updatedAt int64) string {
+	data := map[string]any{
+		"id":                       userID,
+		"username":                 username,
+		"image_url":                "https://img.clerk.com/" + userID + "-" + strconv.FormatInt(updatedAt, 10),
+		"primary_email_address_id": "idn_1",
+		"email_addresses":          []map[string]string{{"id": "idn_1", "email_address": email}},
+		"updated_at":               updatedAt,
+	}
+	if eventType == eventUserDeleted {
+		data = map[string]any{"id": userID, "deleted": true}
+	}
+	body, _ := json.Marshal(map[string]any{"type": eventType, "data": data})
+	return string(body)
+}
+
+// Posts body signed by svix under the delivery id msgID
+func deliver(r *gin.Engine, svix *webhook.Svix, msgID, body string) *httptest.ResponseRecorder {
+	now := time.Now()
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/clerk", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("svix-id", msgID)
+	req.Header.Set("svix-timestamp", strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set("svix-signature", svix.Sign(msgID, now, []byte(body)))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func expectDelivery(t *testing.T, w *httptest.ResponseRecorder, status int, message string) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d; body %s", w.Code, status, w.Body)
+	}
+	if message == "" {
+		return
+	}
+	var got struct{ Message string }
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || got.Message != message {
+		t.Fatalf("body = %s, want message %q", w.Body, message)
+	}
+}
+
+func loadUser(t *testing.T, db *gorm.DB, id string) *models.User {
+	t.Helper()
+	var users []models.User
+	if err := db.Where("id = ?", id).Find(&users).Error; err != nil {
+		t.Fatalf("load user: %v", err)
+	}
+	if len(users) == 0 {
+		return nil
+	}
+	return &users[0]
+}
+
+func TestClerkWebhookUserCreated(t *testing.T) {
+	r, db, svix := clerkWebhookServer(t)
+
+	w := deliver(r, svix, "msg_1", clerkUserEvent(eventUserCreated, "user_1", "alice@vitstudent.ac.in", "alice", 1000))
+	expectDelivery(t, w, http.StatusOK, "Event processed")
+
+	u := loadUser(t, db, "user_1")
+	if u == nil {
+		t.Fatal("user was not created")
+	}
+	if u.Email != "alice@vitstudent.ac.in" || u.Username != "alice" || u.AvatarURL != "https://img.clerk.com/user_1-1000" {
+		t.Errorf("user = %q %q %q, want Clerk's email, username and image", u.Email, u.Username, u.AvatarURL)
+	}
+	if u.ClerkUpdatedAt != 1000 {
+		t.Errorf("clerk_updated_at = %d, want 1000", u.ClerkUpdatedAt)
+	}
+}
+
+func TestClerkWebhookUserCreatedWithoutUsername(t *testing.T) {
+	r, db, svix := clerkWebhookServer(t)
+
+	w := deliver(r, svix, "msg_1", clerkUserEvent(eventUserCreated, "user_1", "alice@vitstudent.ac.in", "", 1000))
+	expectDelivery(t, w, http.StatusOK, "Event processed")
+	if u := loadUser(t, db, "user_1"); u == nil || u.Username != "user_1" {
+		t.Errorf("user = %+v, want the id as username", u)
+	}
+}
+
+func TestClerkWebhookUserUpdatedAppliesRename(t *testing.T) {
+	r, db, svix := clerkWebhookServer(t)
+	expectDelivery(t, deliver(r, svix, "msg_1", clerkUserEvent(eventUserCreated, "user_1", "alice@gmail.com", "alice", 1000)), http.StatusOK, "Event processed")
+
+	// The user switched to their campus address and verified it here
+	now := time.Now()
+	err := db.Model(&models.User{}).Where("id = ?", "user_1").Updates(map[string]any{
+		"email": "alice@vitstudent.ac.in", "verified": true, "verified_at": now,
+	}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := deliver(r, svix, "msg_2", clerkUserEvent(eventUserUpdated, "user_1", "alice@outlook.com", "alice_c", 2000))
+	expectDelivery(t, w, http.StatusOK, "Event processed")
+
+	u := loadUser(t, db, "user_1")
+	if u.Username != "alice_c" || u.AvatarURL != "https://img.clerk.com/user_1-2000" {
+		t.Errorf("user = %q %q, want Clerk's new username and image", u.Username, u.AvatarURL)
+	}
+	if u.Email != "alice@vitstudent.ac.in" {
+		t.Errorf("email = %q, want the verified campus address kept", u.Email)
+	}
+	if !u.Verified || u.VerifiedAt == nil {
+		t.Error("verification was reset")
+	}
+	if u.ClerkUpdatedAt != 2000 {
+		t.Errorf("clerk_updated_at = %d, want 2000", u.ClerkUpdatedAt)
+	}
+}
+
+func TestClerkWebhookUserUpdatedWithoutUsernameKeepsLocalOne(t *testing.T) {
+	r, db, svix := clerkWebhookServer(t)
+	expectDelivery(t, deliver(r, svix, "msg_1", clerkUserEvent(eventUserCreated, "user_1", "alice@vitstudent.ac.in", "alice", 1000)), http.StatusOK, "Event processed")
+
+	w := deliver(r, svix, "msg_2", clerkUserEvent(eventUserUpdated, "user_1", "alice@vitstudent.ac.in", "", 2000))
+	expectDelivery(t, w, http.StatusOK, "Event processed")
+	if u := loadUser(t, db, "user_1"); u.Username != "alice" {
+		t.Errorf("username = %q, want alice", u.Username)
+	}
+}
+
+func TestClerkWebhookRenameConflictIsRetried(t *testing.T) {
+	r, db, svix := clerkWebhookServer(t)
+	expectDelivery(t, deliver(r, svix, "msg_1", clerkUserEvent(eventUserCreated, "user_1", "alice@vitstudent.ac.in", "alice", 1000)), http.StatusOK, "Event processed")
+	expectDelivery(t, deliver(r, svix, "msg_2", clerkUserEvent(eventUserCreated, "user_2", "bob@vitstudent.ac.in", "bob", 1000)), http.StatusOK, "Event processed")
+
+	// Clerk swapped the names; bob's rename is delivered first
+	body := clerkUserEvent(eventUserUpdated, "user_2", "bob@vitstudent.ac.in", "alice", 2000)
+	expectDelivery(t, deliver(r, svix, "msg_3", body), http.StatusConflict, "")
+	if u := loadUser(t, db, "user_1"); u.Username != "alice" {
+		t.Errorf("other user renamed to %q", u.Username)
+	}
+	if u := loadUser(t, db, "user_2"); u.Username != "bob" || u.ClerkUpdatedAt != 1000 {
+		t.Errorf("user = %q at %d, want it unchanged", u.Username, u.ClerkUpdatedAt)
+	}
+
+	expectDelivery(t, deliver(r, svix, "msg_4", clerkUserEvent(eventUserUpdated, "user_1", "alice@vitstudent.ac.in", "carol", 2000)), http.StatusOK, "Event processed")
+	expectDelivery(t, deliver(r, svix, "msg_3", body), http.StatusOK, "Event processed")
+	if u := loadUser(t, db, "user_2"); u.Username != "alice" {
+		t.Errorf("username = %q after the retry, want alice", u.Username)
+	}
+}
+
+func TestClerkWebhookUserUpdatedCreatesMissingUser(t *testing.T) {
+	r, db, svix := clerkWebhookServer(t)
+
+	// user.created was lost or is still being retried
+	w := deliver(r, svix, "msg_2", clerkUserEvent(eventUserUpdated, "user_1", "alice@vitstudent.ac.in", "alice", 2000))
+	expectDelivery(t, w, http.StatusOK, "Event processed")
+	if loadUser(t, db, "user_1") == nil {
+		t.Fatal("user was not created")
+	}
+
+	w = deliver(r, svix, "msg_1", clerkUserEvent(eventUserCreated, "user_1", "alice@vitstudent.ac.in", "alice", 1000))
+	expectDelivery(t, w, http.StatusOK, "Event stale")
+	if u := loadUser(t, db, "user_1"); u.ClerkUpdatedAt != 2000 {
+		t.Errorf("clerk_updated_at = %d, want 2000", u.ClerkUpdatedAt)
+	}
+}
+
+func TestClerkWebhookUserUpdatedSeedsPlaceholder(t *testing.T) {
+	r, db, svix := clerkWebhookServer(t)
+	placeholder := models.User{ID: "user_1", Email: "user_1@" + accounts.OrphanEmailDomain, Username: "user_1"}
+	if err := db.Create(&placeholder).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	w := deliver(r, svix, "msg_1", clerkUserEvent(eventUserUpdated, "user_1", "alice@vitstudent.ac.in", "alice", 1000))
+	expectDelivery(t, w, http.StatusOK, "Event processed")
+	if u := loadUser(t, db, "user_1"); u.Email != "alice@vitstudent.ac.in" || u.Username != "alice" {
+		t.Errorf("user = %q %q, want Clerk's email and username", u.Email, u.Username)
+	}
+}
+
+func TestClerkWebhookUserDeleted(t *testing.T) {
+	r, db, svix := clerkWebhookServer(t)
+	expectDelivery(t, deliver(r, svix, "msg_1", clerkUserEvent(eventUserCreated, "user_1", "alice@vitstudent.ac.in", "alice", 1000)), http.StatusOK, "Event processed")
+	item := models.MarketplaceItem{Title: "Cycle", Price: 1500, Phone: "9876543210", OwnerID: "user_1"}
+	if err := db.Create(&item).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	w := deliver(r, svix, "msg_2", clerkUserEvent(eventUserDeleted, "user_1", "", "", 0))
+	expectDelivery(t, w, http.StatusOK, "Event processed")
+
+	if loadUser(t, db, "user_1") != nil {
+		t.Error("user was not deleted")
+	}
+	var items int64
+	db.Model(&models.MarketplaceItem{}).Where("owner_id = ?", "user_1").Count(&items)
+	if items != 0 {
+		t.Errorf("%d listings left, want them deleted with the user", items)
+	}
+}
+
+func TestClerkWebhookReplayedDelivery(t *testing.T) {
+	r, db, svix := clerkWebhookServer(t)
+	body := clerkUserEvent(eventUserCreated, "user_1", "alice@vitstudent.ac.in", "alice", 1000)
+	expectDelivery(t, deliver(r, svix, "msg_1", body), http.StatusOK, "Event processed")
+
+	// A replay must not undo what happened since
+	if err := db.Model(&models.User{}).Where("id = ?", "user_1").Update("username", "alice_v").Error; err != nil {
+		t.Fatal(err)
+	}
+	expectDelivery(t, deliver(r, svix, "msg_1", body), http.StatusOK, "Event duplicate")
+
+	if u := loadUser(t, db, "user_1"); u.Username != "alice_v" {
+		t.Errorf("username = %q, want alice_v", u.Username)
+	}
+	var events int64
+	db.Model(&models.WebhookEvent{}).Where("id = ?", "msg_1").Count(&events)
+	if events != 1 {
+		t.Errorf("%d events stored for msg_1, want 1", events)
+	}
+}
+
+func TestClerkWebhookUpdateAfterDelete(t *testing.T) {
+	r, db, svix := clerkWebhookServer(t)
+	expectDelivery(t, deliver(r, svix, "msg_1", clerkUserEvent(eventUserCreated, "user_1", "alice@vitstudent.ac.in", "alice", 1000)), http.StatusOK, "Event processed")
+	expectDelivery(t, deliver(r, svix, "msg_3", clerkUserEvent(eventUserDeleted, "user_1", "", "", 0)), http.StatusOK, "Event processed")
+
+	// An update sent before the deletion but delivered after it
+	w := deliver(r, svix, "msg_2", clerkUserEvent(eventUserUpdated, "user_1", "alice@vitstudent.ac.in", "alice", 2000))
+	expectDelivery(t, w, http.StatusOK, "Event stale")
+	if loadUser(t, db, "user_1") != nil {
+		t.Error("deleted user was recreated")
+	}
+}
+
+func TestClerkWebhookStaleUpdate(t *testing.T) {
+	r, db, svix := clerkWebhookServer(t)
+	expectDelivery(t, deliver(r, svix, "msg_1", clerkUserEvent(eventUserCreated, "user_1", "alice@vitstudent.ac.in", "alice", 1000)), http.StatusOK, "Event processed")
+	expectDelivery(t, deliver(r, svix, "msg_3", clerkUserEvent(eventUserUpdated, "user_1", "alice@vitstudent.ac.in", "alice", 3000)), http.StatusOK, "Event processed")
+
+	w := deliver(r, svix, "msg_2", clerkUserEvent(eventUserUpdated, "user_1", "alice@vitstudent.ac.in", "alice", 2000))
+	expectDelivery(t, w, http.StatusOK, "Event stale")
+	if u := loadUser(t, db, "user_1"); u.ClerkUpdatedAt != 3000 {
+		t.Errorf("clerk_updated_at = %d, want 3000", u.ClerkUpdatedAt)
+	}
+}
+
+func TestClerkWebhookConflictIsRetried(t *testing.T) {
+	r, db, svix := clerkWebhookServer(t)
+	other := models.User{ID: "user_0", Email: "alice@vitstudent.ac.in", Username: "someone"}
+	if err := db.Create(&other).Error; err != nil {
+		t.Fatal(err)
+	}
+	body := clerkUserEvent(eventUserCreated, "user_1", "alice@vitstudent.ac.in", "alice", 1000)
+
+	expectDelivery(t, deliver(r, svix, "msg_1", body), http.StatusConflict, "")
+	if loadUser(t, db, "user_1") != nil {
+		t.Error("user was created despite the conflict")
+	}
+	if u := loadUser(t, db, "user_0"); u.Email != "alice@vitstudent.ac.in" || u.Username != "someone" {
+		t.Errorf("other user changed to %q %q", u.Email, u.Username)
+	}
+
+	// Once the other account lets go of the address the retry goes through
+	if err := db.Model(&models.User{}).Where("id = ?", "user_0").Update("email", "other@vitstudent.ac.in").Error; err != nil {
+		t.Fatal(err)
+	}
+	expectDelivery(t, deliver(r, svix, "msg_1", body), http.StatusOK, "Event processed")
+	if loadUser(t, db, "user_1") == nil {
+		t.Error("retried delivery did not create the user")
+	}
+}
+
+func TestClerkWebhookRejectsBadDeliveries(t *testing.T) {
+	r, db, svix := clerkWebhookServer(t)
+	body := clerkUserEvent(eventUserCreated, "user_1", "alice@vitstudent.ac.in", "alice", 1000)
+
+	for name, mutate := range map[string]func(*http.Request){
+		"tampered body": func(req *http.Request) {
+			req.Body = io.NopCloser(strings.NewReader(strings.Replace(body, "alice@", "mallory@", 1)))
+		},
+		"stale timestamp": func(req *http.Request) {
+			old := time.Now().Add(-webhook.Tolerance - time.Minute)
+			req.Header.Set("svix-timestamp", strconv.FormatInt(old.Unix(), 10))
+			req.Header.Set("svix-signature", svix.Sign("msg_1", old, []byte(body)))
+		},
+		"missing signature": func(req *http.Request) { req.Header.Del("svix-signature") },
+	} {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now()
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/clerk", strings.NewReader(body))
+			req.Header.Set("svix-id", "msg_1")
+			req.Header.Set("svix-timestamp", fmt.Sprint(now.Unix()))
+			req.Header.Set("svix-signature", svix.Sign("msg_1", now, []byte(body)))
+			mutate(req)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			expectDelivery(t, w, http.StatusUnauthorized, "")
+		})
+	}
+	if loadUser(t, db, "user_1") != nil {
+		t.Error("a rejected delivery created the user")
+	}
+}
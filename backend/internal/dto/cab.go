@@ -0,0 +1,53 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/patch"
+)
+
+type CreateCabRequest struct {
+	FemaleOnly     bool      `json:"female_only"` // checked against the poster's profile
+	FromLocation   string    `json:"from_location" binding:"required"`
+	ToLocation     string    `json:"to_location" binding:"required"`
+	Date           time.Time `json:"date" binding:"required,future_date"`
+	TimeSlot       string    `json:"time_slot"`
+	SeatsAvailable int       `json:"seats_available" binding:"required,min=1"`
+	Phone          string    `json:"phone" binding:"required,phone"`
+}
+
+func (r CreateCabRequest) Model(ownerID string) models.Cab {
+	return models.Cab{
+		UserID:         ownerID,
+		FemaleOnly:     r.FemaleOnly,
+		FromLocation:   r.FromLocation,
+		ToLocation:     r.ToLocation,
+		Date:           r.Date,
+		TimeSlot:       r.TimeSlot,
+		Seats:          r.SeatsAvailable,
+		SeatsAvailable: r.SeatsAvailable,
+		Phone:          r.Phone,
+	}
+}
+
+// CabPatch holds the fields a merge patch may change on a cab post.
+type CabPatch struct {
+	FromLocation string    `json:"from_location" binding:"required"`
+	ToLocation   string    `json:"to_location" binding:"required"`
+	Date         time.Time `json:"date" binding:"required,future_date"`
+	TimeSlot     string    `json:"time_slot"`
+	Seats        int       `json:"seats" binding:"min=1"`
+	Phone        string    `json:"phone" binding:"required,phone"`
+	FemaleOnly   bool      `json:"female_only"`
+}
+
+var CabPatchFields = patch.Allowlist{
+	"from_location": {},
+	"to_location":   {},
+	"date":          {},
+	"time_slot":     {Nullable: true},
+	"seats":         {},
+	"phone":         {},
+	"female_only":   {},
+}
@@ -0,0 +1,48 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/patch"
+)
+
+type CreateDelibuddyRequest struct {
+	Type         string    `json:"type" binding:"required,oneof=request offer"`
+	Location     string    `json:"location" binding:"required"`
+	Date         time.Time `json:"date" binding:"required,future_date"`
+	TimeSlot     string    `json:"time_slot"`
+	PriceOffered float64   `json:"price_offered" binding:"gte=0"`
+	Phone        string    `json:"phone" binding:"required,phone"`
+}
+
+func (r CreateDelibuddyRequest) Model(ownerID string) models.Delibuddy {
+	return models.Delibuddy{
+		UserID:       ownerID,
+		Type:         r.Type,
+		Location:     r.Location,
+		Date:         r.Date,
+		TimeSlot:     r.TimeSlot,
+		PriceOffered: r.PriceOffered,
+		Phone:        r.Phone,
+	}
+}
+
+// DelibuddyPatch holds the fields a merge patch may change on an entry.
+type DelibuddyPatch struct {
+	Type         string    `json:"type" binding:"required,oneof=request offer"`
+	Location     string    `json:"location" binding:"required"`
+	Date         time.Time `json:"date" binding:"required,future_date"`
+	TimeSlot     string    `json:"time_slot"`
+	PriceOffered float64   `json:"price_offered" binding:"gte=0"`
+	Phone        string    `json:"phone" binding:"required,phone"`
+}
+
+var DelibuddyPatchFields = patch.Allowlist{
+	"type":          {},
+	"location":      {},
+	"date":          {},
+	"time_slot":     {Nullable: true},
+	"price_offered": {Nullable: true},
+	"phone":         {},
+}
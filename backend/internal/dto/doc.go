@@ -0,0 +1,4 @@
+// Package dto holds request bodies, kept apart from the GORM models so the
+// API contract and the table schema can change independently. Validation
+// rules live in `binding` tags; custom rules are registered by package validation.
+package dto
@@ -0,0 +1,10 @@
+package dto
+
+type AttachImageRequest struct {
+	AssetID uint `json:"asset_id" binding:"required,min=1"`
+}
+
+// ReorderImagesRequest lists every attached asset in the new order; the first becomes the cover.
+type ReorderImagesRequest struct {
+	AssetIDs []uint `json:"asset_ids" binding:"required,min=1,dive,min=1"`
+}
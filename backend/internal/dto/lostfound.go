@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/patch"
+)
+
+type CreateLostFoundRequest struct {
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	Category     string `json:"category" binding:"required,oneof=lost found"`
+	ImageAssetID *uint  `json:"image_asset_id" binding:"omitempty,min=1"`
+	Location     string `json:"location"`
+	Phone        string `json:"phone" binding:"required,phone"`
+}
+
+func (r CreateLostFoundRequest) Model(ownerID string) models.LostFound {
+	return models.LostFound{
+		Title:        r.Title,
+		Description:  r.Description,
+		Category:     r.Category,
+		ImageAssetID: r.ImageAssetID,
+		Location:     r.Location,
+		Phone:        r.Phone,
+		OwnerID:      ownerID,
+	}
+}
+
+// LostFoundPatch holds the fields a merge patch may change on an entry.
+type LostFoundPatch struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Category    string `json:"category" binding:"required,oneof=lost found"`
+	Location    string `json:"location"`
+	Phone       string `json:"phone" binding:"required,phone"`
+}
+
+var LostFoundPatchFields = patch.Allowlist{
+	"title":       {Nullable: true},
+	"description": {Nullable: true},
+	"category":    {},
+	"location":    {Nullable: true},
+	"phone":       {},
+}
@@ -0,0 +1,40 @@
+package dto
+
+import (
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/patch"
+)
+
+type CreateMarketplaceItemRequest struct {
+	Title        string  `json:"title"`
+	Description  string  `json:"description"`
+	Price        float64 `json:"price" binding:"gte=0"`
+	ImageAssetID *uint   `json:"image_asset_id" binding:"omitempty,min=1"`
+	Phone        string  `json:"phone" binding:"required,phone"`
+}
+
+func (r CreateMarketplaceItemRequest) Model(ownerID string) models.MarketplaceItem {
+	return models.MarketplaceItem{
+		Title:        r.Title,
+		Description:  r.Description,
+		Price:        r.Price,
+		ImageAssetID: r.ImageAssetID,
+		Phone:        r.Phone,
+		OwnerID:      ownerID,
+	}
+}
+
+// MarketplaceItemPatch holds the fields a merge patch may change on an item.
+type MarketplaceItemPatch struct {
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price" binding:"gte=0"`
+	Phone       string  `json:"phone" binding:"required,phone"`
+}
+
+var MarketplaceItemPatchFields = patch.Allowlist{
+	"title":       {Nullable: true},
+	"description": {Nullable: true},
+	"price":       {},
+	"phone":       {},
+}
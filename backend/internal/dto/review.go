@@ -0,0 +1,12 @@
+package dto
+
+// InteractionRequest records a completed interaction with partner_id.
+type InteractionRequest struct {
+	PartnerID string `json:"partner_id" binding:"required"`
+}
+
+// ReviewRequest rates the other side of an interaction.
+type ReviewRequest struct {
+	Rating  int    `json:"rating" binding:"required,min=1,max=5"`
+	Comment string `json:"comment" binding:"max=1000"`
+}
@@ -0,0 +1,83 @@
+package dto
+
+import (
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/patch"
+)
+
+type CreateUserRequest struct {
+	ID        string `json:"id" binding:"required"` // Clerk User ID
+	Email     string `json:"email" binding:"required,campus_email"`
+	Username  string `json:"username" binding:"required"`
+	AvatarURL string `json:"avatar_url" binding:"omitempty,url"`
+}
+
+func (r CreateUserRequest) Model() models.User {
+	return models.User{
+		ID:        r.ID,
+		Email:     r.Email,
+		Username:  r.Username,
+		AvatarURL: r.AvatarURL,
+	}
+}
+
+// UserPatch holds the fields a merge patch may change on a user. Hostel,
+// block and programme are checked against the admin-managed lists by the
+// handler.
+type UserPatch struct {
+	Email     string `json:"email" binding:"required,campus_email"`
+	Username  string `json:"username" binding:"required"`
+	AvatarURL string `json:"avatar_url" binding:"omitempty,url"`
+
+	Hostel    string                `json:"hostel" binding:"required,max=20"`
+	Block     string                `json:"block" binding:"required,max=20"`
+	Room      string                `json:"room" binding:"required,max=20"`
+	Year      int                   `json:"year" binding:"min=1,max=6"`
+	Programme string                `json:"programme" binding:"required,max=20"`
+	Gender    string                `json:"gender" binding:"oneof=female male other"`
+	Phone     string                `json:"phone" binding:"required,phone"`
+	Privacy   models.ProfilePrivacy `json:"privacy" binding:"dive,keys,oneof=hostel room year programme gender phone,endkeys,oneof=public campus private"`
+}
+
+// Profile fields are nullable: null removes them from the profile. privacy
+// is merged into the current settings rather than replacing them.
+var UserPatchFields = patch.Allowlist{
+	"email":      {},
+	"username":   {},
+	"avatar_url": {Nullable: true},
+	"hostel":     {Nullable: true},
+	"block":      {Nullable: true},
+	"room":       {Nullable: true},
+	"year":       {Nullable: true},
+	"programme":  {Nullable: true},
+	"gender":     {Nullable: true},
+	"phone":      {Nullable: true},
+	"privacy":    {},
+}
+
+// ConfirmEmailRequest carries the one-time code from the verification email.
+type ConfirmEmailRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+// HostelRequest creates or replaces a hostel; the code comes from the path.
+type HostelRequest struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Blocks []string `json:"blocks" binding:"max=50,dive,required,max=20"`
+}
+
+// ProgrammeRequest creates or replaces a programme; the code comes from the path.
+type ProgrammeRequest struct {
+	Name  string `json:"name" binding:"required,max=100"`
+	Years int    `json:"years" binding:"required,min=1,max=6"`
+}
+
+// GenderReviewRequest is an admin's verdict on a user's attested gender.
+type GenderReviewRequest struct {
+	Status string `json:"status" binding:"required,oneof=verified rejected"`
+}
+
+// BlockRequest blocks or only mutes another user.
+type BlockRequest struct {
+	Kind string `json:"kind" binding:"required,oneof=block mute"`
+}
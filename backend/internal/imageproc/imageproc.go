@@ -0,0 +1,233 @@
+// Package imageproc validates uploaded images and re-encodes them so that no
+// metadata (EXIF, GPS, camera serials, embedded thumbnails) reaches storage.
+package imageproc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	"github.com/gabriel-vasile/mimetype"
+	_ "golang.org/x/image/webp" // registers the WebP decoder
+)
+
+// Supported maps the accepted MIME types to what they are stored as.
+// There is no pure-Go WebP encoder, so WebP is stored as JPEG (or PNG when it
+// has transparency).
+var Supported = map[string]imaging.Format{
+	"image/jpeg": imaging.JPEG,
+	"image/png":  imaging.PNG,
+	"image/webp": imaging.JPEG,
+}
+
+var (
+	ErrTooLarge    = errors.New("file is too large")
+	ErrUnsupported = errors.New("unsupported file type")
+	ErrDimensions  = errors.New("image dimensions out of range")
+	ErrCorrupt     = errors.New("image cannot be decoded")
+)
+
+const jpegQuality = 85
+
+// Limits bound what an upload may be.
+type Limits struct {
+	MaxBytes     int64
+	MaxDimension int // longest side, in pixels
+	MinDimension int // shortest side, in pixels
+	AllowedTypes []string
+}
+
+var DefaultLimits = Limits{
+	MaxBytes:     10 << 20,
+	MaxDimension: 8000,
+	MinDimension: 16,
+	AllowedTypes: []string{"image/jpeg", "image/png", "image/webp"},
+}
+
+// LimitsFromEnv reads UPLOAD_MAX_BYTES, UPLOAD_MAX_DIMENSION,
+// UPLOAD_MIN_DIMENSION and UPLOAD_ALLOWED_TYPES (comma-separated MIME types),
+// keeping the default for unset or malformed values.
+func LimitsFromEnv() Limits {
+	l := DefaultLimits
+	l.MaxBytes = int64(envInt("UPLOAD_MAX_BYTES", int(l.MaxBytes)))
+	l.MaxDimension = envInt("UPLOAD_MAX_DIMENSION", l.MaxDimension)
+	l.MinDimension = envInt("UPLOAD_MIN_DIMENSION", l.MinDimension)
+
+	if v := os.Getenv("UPLOAD_ALLOWED_TYPES"); v != "" {
+		var types []string
+		for _, t := range strings.Split(v, ",") {
+			t = strings.ToLower(strings.TrimSpace(t))
+			if _, ok := Supported[t]; !ok {
+				slog.Warn("Ignoring unsupported upload type", "env", "UPLOAD_ALLOWED_TYPES", "type", t)
+				continue
+			}
+			types = append(types, t)
+		}
+		if len(types) > 0 {
+			l.AllowedTypes = types
+		}
+	}
+	return l
+}
+
+func envInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		slog.Warn("Invalid upload limit, using default", "env", key, "value", v)
+		return def
+	}
+	return n
+}
+
+func (l Limits) allows(mime string) bool {
+	for _, t := range l.AllowedTypes {
+		if t == mime {
+			return true
+		}
+	}
+	return false
+}
+
+// Image is a sanitized image ready to be stored.
+type Image struct {
+	Data        []byte
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+	Hash        uint64 // DHash of the pixels; only set on the original
+}
+
+// Variant names. The original is the full image after sanitizing; smaller
+// variants are only produced when the original is larger than their size.
+const (
+	VariantThumb    = "thumb"
+	VariantMedium   = "medium"
+	VariantOriginal = "original"
+)
+
+// VariantSizes is the longest side of each resized variant, in pixels.
+var VariantSizes = map[string]int{
+	VariantThumb:  320,
+	VariantMedium: 1024,
+}
+
+// Variant is one encoded rendition of an upload.
+type Variant struct {
+	Name string
+	*Image
+}
+
+// Process checks data against limits by content (the client's file name and
+// Content-Type are ignored), applies the EXIF orientation and re-encodes the
+// pixels, which drops all metadata. It returns the original followed by every
+// resized variant that is smaller than it. Errors wrap the Err* values above.
+func Process(data []byte, limits Limits) ([]Variant, error) {
+	img, format, err := decode(data, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	original, err := Encode(img, format)
+	if err != nil {
+		return nil, err
+	}
+	original.Hash = DHash(img)
+	variants := []Variant{{Name: VariantOriginal, Image: original}}
+
+	longest := max(original.Width, original.Height)
+	for _, name := range []string{VariantMedium, VariantThumb} {
+		size := VariantSizes[name]
+		if size >= longest {
+			continue
+		}
+		resized, err := Encode(imaging.Fit(img, size, size, imaging.Lanczos), format)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, Variant{Name: name, Image: resized})
+	}
+	return variants, nil
+}
+
+// Validates and decodes an upload, returning the format to store it as
+func decode(data []byte, limits Limits) (image.Image, imaging.Format, error) {
+	if int64(len(data)) > limits.MaxBytes {
+		return nil, 0, fmt.Errorf("%w: limit is %d bytes", ErrTooLarge, limits.MaxBytes)
+	}
+
+	mime := mimetype.Detect(data).String()
+	if _, ok := Supported[mime]; !ok || !limits.allows(mime) {
+		return nil, 0, fmt.Errorf("%w: %s (allowed: %s)", ErrUnsupported, mime, strings.Join(limits.AllowedTypes, ", "))
+	}
+
+	// Read the header first so a small file claiming huge dimensions is
+	// rejected before the pixels are allocated
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	if err := checkDimensions(cfg.Width, cfg.Height, limits); err != nil {
+		return nil, 0, err
+	}
+
+	img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+
+	format := Supported[mime]
+	if format == imaging.JPEG && mime != "image/jpeg" && !isOpaque(img) {
+		format = imaging.PNG
+	}
+	return img, format, nil
+}
+
+// Encode writes img without any metadata as JPEG or PNG.
+func Encode(img image.Image, format imaging.Format) (*Image, error) {
+	var buf bytes.Buffer
+	out := &Image{Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+	switch format {
+	case imaging.PNG:
+		out.ContentType, out.Ext = "image/png", ".png"
+		if err := imaging.Encode(&buf, img, imaging.PNG, imaging.PNGCompressionLevel(png.BestCompression)); err != nil {
+			return nil, err
+		}
+	default:
+		out.ContentType, out.Ext = "image/jpeg", ".jpg"
+		if err := imaging.Encode(&buf, img, imaging.JPEG, imaging.JPEGQuality(jpegQuality)); err != nil {
+			return nil, err
+		}
+	}
+	out.Data = buf.Bytes()
+	return out, nil
+}
+
+func checkDimensions(w, h int, limits Limits) error {
+	longest, shortest := max(w, h), min(w, h)
+	if longest > limits.MaxDimension {
+		return fmt.Errorf("%w: %dx%d exceeds %d px", ErrDimensions, w, h, limits.MaxDimension)
+	}
+	if shortest < limits.MinDimension {
+		return fmt.Errorf("%w: %dx%d is below %d px", ErrDimensions, w, h, limits.MinDimension)
+	}
+	return nil
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
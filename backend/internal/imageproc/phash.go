@@ -0,0 +1,32 @@
+package imageproc
+
+import (
+	"image"
+	"math/bits"
+
+	"github.com/disintegration/imaging"
+)
+
+// DHash is a 64-bit difference hash: the image is shrunk to 9x8 grey pixels
+// and each bit records whether a pixel is brighter than its right neighbour.
+// Re-encoded, resized or lightly edited copies of a photo hash to values a
+// few bits apart, unlike a checksum of the file.
+func DHash(img image.Image) uint64 {
+	small := imaging.Resize(imaging.Grayscale(img), 9, 8, imaging.Box)
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			// Grayscale leaves R, G and B equal
+			if small.Pix[small.PixOffset(x, y)] > small.Pix[small.PixOffset(x+1, y)] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// Distance is the number of bits in which two hashes differ, 0 to 64.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
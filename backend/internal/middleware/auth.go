@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/auth"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Authenticate verifies an "Authorization: Bearer <token>" header and stores
+// the user id under UserIDKey. Requests without the header continue
+// anonymously; a header with a bad token is rejected with 401.
+func Authenticate(v auth.Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		scheme, token, _ := strings.Cut(header, " ")
+		if !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+			abortUnauthorized(c, "Authorization header must be a bearer token")
+			return
+		}
+		if v == nil {
+			abortUnauthorized(c, "Authentication is not configured")
+			return
+		}
+
+		userID, err := v.Verify(c.Request.Context(), strings.TrimSpace(token))
+		if errors.Is(err, auth.ErrInvalidToken) {
+			abortUnauthorized(c, "Invalid or expired token")
+			return
+		}
+		if err != nil {
+			Log(c).Error("Token verification failed", "error", err)
+			apierror.Abort(c, apierror.Internal("Failed to verify token"))
+			return
+		}
+
+		c.Set(UserIDKey, userID)
+		c.Next()
+	}
+}
+
+// RequireUser rejects anonymous requests with 401.
+func RequireUser(c *gin.Context) {
+	if UserID(c) == "" {
+		abortUnauthorized(c, "Sign in to use this endpoint")
+		return
+	}
+	c.Next()
+}
+
+// RequireVerified guards write endpoints: the caller must be signed in and
+// must have verified their campus email.
+func RequireVerified(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := UserID(c)
+		if userID == "" {
+			abortUnauthorized(c, "Sign in to use this endpoint")
+			return
+		}
+
+		var verified []bool
+		err := db.WithContext(c.Request.Context()).Model(&models.User{}).
+			Where("id = ?", userID).Pluck("verified", &verified).Error
+		if err != nil {
+			Log(c).Error("Failed to load verification status", "error", err)
+			apierror.Abort(c, apierror.Internal("Failed to check verification status"))
+			return
+		}
+		if len(verified) == 0 || !verified[0] {
+			apierror.Abort(c, apierror.New(http.StatusForbidden, apierror.CodeUnverified,
+				"Verify your campus email address before posting"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAdmin rejects callers whose role is not admin with 403.
+func RequireAdmin(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := UserID(c)
+		if userID == "" {
+			abortUnauthorized(c, "Sign in to use this endpoint")
+			return
+		}
+		var roles []string
+		err := db.WithContext(c.Request.Context()).Model(&models.User{}).
+			Where("id = ?", userID).Pluck("role", &roles).Error
+		if err != nil {
+			Log(c).Error("Failed to load role", "error", err)
+			apierror.Abort(c, apierror.Internal("Failed to check permissions"))
+			return
+		}
+		if len(roles) == 0 || roles[0] != models.RoleAdmin {
+			apierror.Abort(c, apierror.Forbidden("Admins only"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// UserID returns the authenticated user's id, or "" for anonymous requests.
+func UserID(c *gin.Context) string {
+	return c.GetString(UserIDKey)
+}
+
+func abortUnauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", `Bearer realm="vitbuddy"`)
+	apierror.Abort(c, apierror.Unauthorized(message))
+}
@@ -0,0 +1,233 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyHeader carries a client-generated key (e.g. a UUID) that makes
+// retries of the same create request safe.
+const IdempotencyHeader = "Idempotency-Key"
+
+const maxIdempotencyKeyLen = 255
+
+// IdempotencyStore remembers requests by key. Keys are already scoped to the
+// route and caller by the middleware.
+type IdempotencyStore interface {
+	// Reserve claims key for a new request. If the key is already taken the
+	// existing record is returned with created == false.
+	Reserve(ctx context.Context, key, fingerprint string, expiresAt time.Time) (rec *models.IdempotencyKey, created bool, err error)
+	// Complete stores the response of the request that reserved key.
+	Complete(ctx context.Context, key string, status int, contentType string, body []byte) error
+	// Release forgets key so the request can be retried (used after server errors).
+	Release(ctx context.Context, key string) error
+}
+
+// GormIdempotencyStore keeps keys in the database so they survive restarts
+// and are shared between instances.
+type GormIdempotencyStore struct {
+	db *gorm.DB
+}
+
+// NewGormIdempotencyStore creates a database-backed store and starts a
+// janitor that deletes expired keys.
+func NewGormIdempotencyStore(db *gorm.DB, cleanupEvery time.Duration) *GormIdempotencyStore {
+	s := &GormIdempotencyStore{db: db}
+	go func() {
+		for now := range time.Tick(cleanupEvery) {
+			if err := s.db.Where("expires_at <= ?", now).Delete(&models.IdempotencyKey{}).Error; err != nil {
+				slog.Error("Failed to delete expired idempotency keys", "error", err)
+			}
+		}
+	}()
+	return s
+}
+
+func (s *GormIdempotencyStore) Reserve(ctx context.Context, key, fingerprint string, expiresAt time.Time) (*models.IdempotencyKey, bool, error) {
+	db := s.db.WithContext(ctx)
+
+	// An expired key is free to be reused even if the janitor has not run yet
+	err := db.Where(&models.IdempotencyKey{Key: key}).Where("expires_at <= ?", time.Now()).
+		Delete(&models.IdempotencyKey{}).Error
+	if err != nil {
+		return nil, false, err
+	}
+
+	rec := models.IdempotencyKey{Key: key, Fingerprint: fingerprint, ExpiresAt: expiresAt}
+	res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rec)
+	if res.Error != nil {
+		return nil, false, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return &rec, true, nil
+	}
+
+	var existing models.IdempotencyKey
+	if err := db.Where(&models.IdempotencyKey{Key: key}).First(&existing).Error; err != nil {
+		return nil, false, err
+	}
+	return &existing, false, nil
+}
+
+func (s *GormIdempotencyStore) Complete(ctx context.Context, key string, status int, contentType string, body []byte) error {
+	return s.db.WithContext(ctx).Model(&models.IdempotencyKey{}).
+		Where(&models.IdempotencyKey{Key: key}).
+		Updates(map[string]any{"status_code": status, "content_type": contentType, "body": body}).Error
+}
+
+func (s *GormIdempotencyStore) Release(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Where(&models.IdempotencyKey{Key: key}).
+		Delete(&models.IdempotencyKey{}).Error
+}
+
+// Idempotency makes a create endpoint safe to retry. When the request carries
+// an Idempotency-Key header:
+//   - the first request runs normally and its response is stored for window;
+//   - a retry with the same body gets the stored response, with an
+//     Idempotent-Replayed: true header, without running the handler again;
+//   - reusing the key with a different body is rejected with 422;
+//   - a retry while the first request is still running gets 409.
+//
+// Server errors (5xx) and 429s are not stored, so the client can retry them.
+// Requests without the header are not affected.
+func Idempotency(store IdempotencyStore, window time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idemKey := c.GetHeader(IdempotencyHeader)
+		if idemKey == "" {
+			c.Next()
+			return
+		}
+		if len(idemKey) > maxIdempotencyKeyLen {
+			apierror.Abort(c, apierror.Field(IdempotencyHeader, "Idempotency-Key must be at most 255 characters"))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			apierror.Abort(c, apierror.InvalidJSON("Cannot read request body"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Keys only have to be unique per caller and route
+		caller := "ip:" + c.ClientIP()
+		if userID := c.GetString(UserIDKey); userID != "" {
+			caller = "user:" + userID
+		}
+		key := c.Request.Method + " " + c.FullPath() + " " + caller + " " + idemKey
+		fingerprint := requestFingerprint(c.Request.URL.RawQuery, body)
+
+		// A dropped connection must not leave the key stuck half-written
+		ctx := context.WithoutCancel(c.Request.Context())
+
+		rec, created, err := store.Reserve(ctx, key, fingerprint, time.Now().Add(window))
+		if err != nil {
+			Log(c).Error("Idempotency store failed", "error", err)
+			apierror.Abort(c, apierror.Internal("Failed to check idempotency key"))
+			return
+		}
+
+		if !created {
+			switch {
+			case rec.Fingerprint != fingerprint:
+				apierror.Abort(c, apierror.New(http.StatusUnprocessableEntity, apierror.CodeIdempotencyReuse,
+					"Idempotency-Key was already used with a different request"))
+			case rec.StatusCode == 0:
+				apierror.Abort(c, apierror.Conflict("A request with this Idempotency-Key is still being processed"))
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(rec.StatusCode, rec.ContentType, rec.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		recorder := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		completed := false
+		defer func() {
+			// Panics and server errors release the key so a retry runs again
+			if completed {
+				return
+			}
+			if err := store.Release(ctx, key); err != nil {
+				Log(c).Error("Failed to release idempotency key", "error", err)
+			}
+		}()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if status >= http.StatusInternalServerError || status == http.StatusTooManyRequests {
+			return
+		}
+		err = store.Complete(ctx, key, status, c.Writer.Header().Get("Content-Type"), recorder.body.Bytes())
+		if err != nil {
+			Log(c).Error("Failed to store idempotent response", "error", err)
+			return
+		}
+		completed = true
+	}
+}
+
+// IdempotencyWindowFromEnv reads IDEMPOTENCY_WINDOW as a duration, e.g. "24h",
+// falling back to def when unset or malformed.
+func IdempotencyWindowFromEnv(def time.Duration) time.Duration {
+	v := os.Getenv("IDEMPOTENCY_WINDOW")
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		slog.Warn("Invalid idempotency window, using default", "env", "IDEMPOTENCY_WINDOW", "value", v)
+		return def
+	}
+	return d
+}
+
+// Hash of the query and the body. JSON bodies are re-encoded first so that
+// key order and whitespace do not make a retry look like a different request.
+func requestFingerprint(query string, body []byte) string {
+	var doc any
+	if err := json.Unmarshal(body, &doc); err == nil {
+		if canonical, err := json.Marshal(doc); err == nil {
+			body = canonical
+		}
+	}
+	h := sha256.New()
+	h.Write([]byte(query))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Copies everything written to the response so it can be stored
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+)
+
+// UserIDKey is where an auth middleware stores the authenticated user's ID.
+// Rate limits are keyed by it when present and by client IP otherwise.
+const UserIDKey = "auth_user_id"
+
+// Policy is a token bucket: Limit requests per Period, refilled continuously.
+type Policy struct {
+	Name   string
+	Limit  int
+	Period time.Duration
+}
+
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+// RateLimitStore holds bucket state. The in-memory store works for a single
+// instance; a shared implementation (e.g. Redis) can be swapped in for several.
+type RateLimitStore interface {
+	// Take consumes one token for key and reports how long to wait if none is left.
+	Take(ctx context.Context, key string, p Policy, now time.Time) (allowed bool, retryAfter time.Duration, err error)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	period time.Duration
+}
+
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// NewMemoryStore creates an in-process store and starts a janitor that drops
+// buckets idle for longer than their period (they would be full anyway).
+func NewMemoryStore(cleanupEvery time.Duration) *MemoryStore {
+	s := &MemoryStore{buckets: map[string]*bucket{}}
+	go func() {
+		for now := range time.Tick(cleanupEvery) {
+			s.cleanup(now)
+		}
+	}()
+	return s
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, p Policy, now time.Time) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(p.Limit), last: now, period: p.Period}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(p.Limit), b.tokens+now.Sub(b.last).Seconds()*p.rate())
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+
+	wait := time.Duration((1 - b.tokens) / p.rate() * float64(time.Second))
+	return false, wait, nil
+}
+
+func (s *MemoryStore) cleanup(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, b := range s.buckets {
+		if now.Sub(b.last) > b.period {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// RateLimit rejects requests over the policy with 429 and a Retry-After header.
+// If the store fails the request is let through rather than taking the API down.
+func RateLimit(store RateLimitStore, p Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := "ip:" + c.ClientIP()
+		if userID := c.GetString(UserIDKey); userID != "" {
+			key = "user:" + userID
+		}
+
+		allowed, retryAfter, err := store.Take(c.Request.Context(), p.Name+":"+key, p, time.Now())
+		if err != nil {
+			Log(c).Error("Rate limit store failed", "policy", p.Name, "error", err)
+			c.Next()
+			return
+		}
+		if !allowed {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			apierror.Abort(c, apierror.New(http.StatusTooManyRequests, apierror.CodeRateLimited,
+				fmt.Sprintf("Too many requests, retry in %d seconds", seconds)))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// PolicyFromEnv reads RATE_LIMIT_<NAME> as "<limit>/<period>", e.g. "10/1m",
+// falling back to def when unset or malformed.
+func PolicyFromEnv(name string, def string) Policy {
+	envKey := "RATE_LIMIT_" + strings.ToUpper(name)
+	if v := os.Getenv(envKey); v != "" {
+		p, err := parsePolicy(name, v)
+		if err == nil {
+			return p
+		}
+		slog.Warn("Invalid rate limit policy, using default", "env", envKey, "value", v, "error", err)
+	}
+
+	p, err := parsePolicy(name, def)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// TrustedProxiesFromEnv reads TRUSTED_PROXIES, a comma-separated list of
+// the IPs or CIDRs of the reverse proxies in front of the server. Only
+// requests from those peers may set the client IP through X-Forwarded-For;
+// unset means the server is reached directly and the peer address is used,
+// so anonymous rate limits and idempotency keys cannot be spoofed.
+func TrustedProxiesFromEnv() []string {
+	var proxies []string
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
+}
+
+func parsePolicy(name, spec string) (Policy, error) {
+	limitStr, periodStr, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Policy{}, fmt.Errorf("rate limit %q: expected <limit>/<period>", spec)
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(limitStr))
+	if err != nil || limit <= 0 {
+		return Policy{}, fmt.Errorf("rate limit %q: limit must be a positive integer", spec)
+	}
+	period, err := time.ParseDuration(strings.TrimSpace(periodStr))
+	if err != nil || period <= 0 {
+		return Policy{}, fmt.Errorf("rate limit %q: invalid period", spec)
+	}
+	return Policy{Name: name, Limit: limit, Period: period}, nil
+}
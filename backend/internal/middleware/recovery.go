@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+)
+
+// Recovery turns panics into the standard error envelope and logs the stack
+// with the request ID, instead of gin's bare 500.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				Log(c).Error("Panic recovered", "panic", r, "stack", string(debug.Stack()))
+				if c.Writer.Written() {
+					c.Abort()
+					return
+				}
+				apierror.Abort(c, apierror.Internal("Internal server error"))
+			}
+		}()
+		c.Next()
+	}
+}
+
+// NoRoute and NoMethod answer unknown routes with the error envelope.
+func NoRoute(c *gin.Context) {
+	apierror.Abort(c, apierror.New(http.StatusNotFound, apierror.CodeRouteNotFound, "Route not found"))
+}
+
+func NoMethod(c *gin.Context) {
+	apierror.Abort(c, apierror.New(http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed"))
+}
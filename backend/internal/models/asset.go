@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Asset states. An asset is attached while some listing shows it; pending
+// (never used) and detached assets are deleted by the orphan collector once
+// they have been in that state for its grace period.
+const (
+	AssetPending  = "pending"
+	AssetAttached = "attached"
+	AssetDetached = "detached"
+)
+
+// Asset is an uploaded image and its stored renditions, keyed by variant
+// name ("thumb", "medium", "original"). Small uploads point the larger
+// variant names at the original instead of storing copies.
+type Asset struct {
+	ID         uint                    `gorm:"primaryKey;autoIncrement" json:"id"`
+	UploaderID string                  `gorm:"index;not null;default:''" json:"uploader_id"` // Clerk user id
+	Width      int                     `gorm:"not null" json:"width"`
+	Height     int                     `gorm:"not null" json:"height"`
+	Variants   map[string]AssetVariant `gorm:"type:jsonb;serializer:json;not null" json:"variants"`
+	Bytes      int64                   `gorm:"not null;default:0" json:"bytes"` // stored size of all variants, for quotas
+	PHash      *int64                  `gorm:"index" json:"-"`                  // perceptual hash (imageproc.DHash) bits; nil for old uploads
+	State      string                  `gorm:"type:varchar(10);not null;default:'pending';index" json:"state"`
+	StateSince time.Time               `gorm:"not null;default:CURRENT_TIMESTAMP" json:"state_since"`
+	CreatedAt  time.Time               `json:"created_at"`
+}
+
+// StoredBytes adds up the variants' sizes, counting shared objects once.
+func (a *Asset) StoredBytes() int64 {
+	var total int64
+	seen := map[string]bool{}
+	for _, v := range a.Variants {
+		if !seen[v.Key] {
+			seen[v.Key] = true
+			total += int64(v.Bytes)
+		}
+	}
+	return total
+}
+
+type AssetVariant struct {
+	Key         string `json:"key"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Bytes       int    `json:"bytes"`
+}
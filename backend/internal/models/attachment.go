@@ -0,0 +1,21 @@
+package models
+
+// Listing types, stored in AssetAttachment.ListingType.
+const (
+	ListingLostFound   = "lostfound"
+	ListingMarketplace = "marketplace"
+	ListingDelibuddy   = "delibuddy"
+	ListingCab         = "cab"
+)
+
+// AssetAttachment places an uploaded image on a listing. Images are shown in
+// Position order and the first one is the cover. An asset belongs to at most
+// one listing, and a position is used once per listing.
+type AssetAttachment struct {
+	ID          uint   `gorm:"primaryKey;autoIncrement" json:"-"`
+	ListingType string `gorm:"type:varchar(20);not null;uniqueIndex:idx_attachment_position" json:"-"`
+	ListingID   uint   `gorm:"not null;uniqueIndex:idx_attachment_position" json:"-"`
+	AssetID     uint   `gorm:"not null;uniqueIndex" json:"asset_id"`
+	Asset       *Asset `gorm:"constraint:OnDelete:CASCADE" json:"asset,omitempty"`
+	Position    int    `gorm:"not null;uniqueIndex:idx_attachment_position" json:"position"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Kinds of block
+const (
+	BlockFull = "block" // both ways: neither sees the other's listings or can join or deal with them
+	BlockMute = "mute"  // only hides the muted user's listings from the muter
+)
+
+// Block is one user blocking or muting another.
+type Block struct {
+	BlockerID string       `gorm:"primaryKey" json:"blocker_id"`
+	Blocker   *UserSummary `gorm:"foreignKey:BlockerID;constraint:OnDelete:CASCADE" json:"-"`
+	BlockedID string       `gorm:"primaryKey;index" json:"blocked_id"`
+	Blocked   *UserSummary `gorm:"foreignKey:BlockedID;constraint:OnDelete:CASCADE" json:"blocked,omitempty"`
+	Kind      string       `gorm:"type:varchar(10);not null;default:'block';check:kind IN ('block','mute')" json:"kind"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
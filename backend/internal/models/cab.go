@@ -3,18 +3,29 @@ package models
 import "time"
 
 type Cab struct {
-	ID             uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	UserID         string    `gorm:"not null" json:"user_id"`       // Clerk user id
-	Username       string    `gorm:"not null" json:"username"`      // display name
-	Gender         string    `json:"gender"`                        // gender of poster
-	FemaleOnly     bool      `gorm:"default:false" json:"female_only"` // only female users can join
-	FromLocation   string    `gorm:"not null" json:"from_location"` // start
-	ToLocation     string    `gorm:"not null" json:"to_location"`   // destination
-	Date           time.Time `gorm:"not null" json:"date"`          // ride date
-	TimeSlot       string    `json:"time_slot,omitempty"`           // optional
-	SeatsAvailable int       `gorm:"not null" json:"seats_available"`
-	Phone          string    `gorm:"not null" json:"phone"`
+	ID             uint              `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID         string            `gorm:"not null;index" json:"user_id"` // Clerk user id
+	Owner          *UserSummary      `gorm:"foreignKey:UserID;constraint:OnDelete:RESTRICT" json:"owner,omitempty"`
+	Gender         string            `json:"gender"`                           // "female" on female-only rides, otherwise not disclosed
+	FemaleOnly     bool              `gorm:"default:false" json:"female_only"` // only female users can join
+	FromLocation   string            `gorm:"not null" json:"from_location"`    // start
+	ToLocation     string            `gorm:"not null" json:"to_location"`      // destination
+	Date           time.Time         `gorm:"not null" json:"date"`             // ride date
+	TimeSlot       string            `json:"time_slot,omitempty"`              // optional
+	Seats          int               `gorm:"not null;default:0" json:"seats"`  // offered in total, joined passengers included
+	SeatsAvailable int               `gorm:"not null" json:"seats_available"`  // Seats minus the passengers who joined
+	Phone          string            `gorm:"not null" json:"phone"`
+	Images         []AssetAttachment `gorm:"polymorphic:Listing;polymorphicValue:cab" json:"images"` // ordered, first is the cover
 
+	Version   uint      `gorm:"not null;default:1" json:"version"` // bumped on every update
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
+
+// CabPassenger is a user who joined a cab; each join takes one of its seats.
+type CabPassenger struct {
+	CabID     uint      `gorm:"primaryKey" json:"cab_id"`
+	UserID    string    `gorm:"primaryKey;index" json:"user_id"`
+	Cab       *Cab      `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
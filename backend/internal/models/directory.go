@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Hostel is an entry of the admin-managed hostel list that profiles pick from.
+type Hostel struct {
+	Code      string    `gorm:"primaryKey;type:varchar(20)" json:"code"` // e.g. "MH1"
+	Name      string    `gorm:"not null" json:"name"`
+	Blocks    []string  `gorm:"type:jsonb;serializer:json;not null" json:"blocks"` // empty when the hostel has no blocks
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Programme is an entry of the admin-managed list of degree programmes.
+type Programme struct {
+	Code      string    `gorm:"primaryKey;type:varchar(20)" json:"code"` // e.g. "BCE"
+	Name      string    `gorm:"not null" json:"name"`
+	Years     int       `gorm:"not null" json:"years"` // duration, the highest valid year of study
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
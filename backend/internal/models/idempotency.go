@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// IdempotencyKey remembers the outcome of a create request so a retried
+// request with the same Idempotency-Key gets the original response.
+type IdempotencyKey struct {
+	Key         string    `gorm:"primaryKey" json:"key"`
+	Fingerprint string    `gorm:"not null" json:"fingerprint"`
+	StatusCode  int       `gorm:"not null;default:0" json:"status_code"` // 0 while the first request is still running
+	ContentType string    `json:"content_type"`
+	Body        []byte    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `gorm:"not null;index" json:"expires_at"`
+}
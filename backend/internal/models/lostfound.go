@@ -8,15 +8,20 @@ const (
 )
 
 type LostFound struct {
-	ID          uint    `gorm:"primaryKey;autoIncrement" json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Category    string    `gorm:"type:varchar(10);check:category IN ('lost','found');not null" json:"category"`
-	ImageURL    string    `json:"image_url"`
-	Location    string    `json:"location"`
-	Phone       string    `gorm:"not null" json:"phone"`
-	OwnerID     string    `gorm:"not null" json:"owner_id"`
+	ID           uint              `gorm:"primaryKey;autoIncrement" json:"id"`
+	Title        string            `json:"title"`
+	Description  string            `json:"description"`
+	Category     string            `gorm:"type:varchar(10);check:category IN ('lost','found');not null" json:"category"`
+	ImageURL     string            `json:"image_url"`
+	ImageAssetID *uint             `json:"image_asset_id"`
+	Image        *Asset            `gorm:"foreignKey:ImageAssetID;constraint:OnDelete:SET NULL" json:"image,omitempty"`
+	Location     string            `json:"location"`
+	Phone        string            `gorm:"not null" json:"phone"`
+	OwnerID      string            `gorm:"not null;index" json:"owner_id"`
+	Owner        *UserSummary      `gorm:"foreignKey:OwnerID;constraint:OnDelete:RESTRICT" json:"owner,omitempty"`
+	Images       []AssetAttachment `gorm:"polymorphic:Listing;polymorphicValue:lostfound" json:"images"` // ordered, first is the cover
 
+	Version   uint      `gorm:"not null;default:1" json:"version"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
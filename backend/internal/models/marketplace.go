@@ -3,13 +3,18 @@ package models
 import "time"
 
 type MarketplaceItem struct {
-	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	Title       string    `json:"title"`       
-	Description string    `json:"description"` 
-	Price       float64   `gorm:"not null" json:"price"`
-	ImageURL    string    `json:"image_url"`
-	Phone       string    `gorm:"not null" json:"phone"`
-	OwnerID     string    `gorm:"not null" json:"owner_id"` 
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID           uint              `gorm:"primaryKey;autoIncrement" json:"id"`
+	Title        string            `json:"title"`
+	Description  string            `json:"description"`
+	Price        float64           `gorm:"not null" json:"price"`
+	ImageURL     string            `json:"image_url"`
+	ImageAssetID *uint             `json:"image_asset_id"`
+	Image        *Asset            `gorm:"foreignKey:ImageAssetID;constraint:OnDelete:SET NULL" json:"image,omitempty"`
+	Phone        string            `gorm:"not null" json:"phone"`
+	OwnerID      string            `gorm:"not null;index" json:"owner_id"`
+	Owner        *UserSummary      `gorm:"foreignKey:OwnerID;constraint:OnDelete:RESTRICT" json:"owner,omitempty"`
+	Images       []AssetAttachment `gorm:"polymorphic:Listing;polymorphicValue:marketplace" json:"images"` // ordered, first is the cover
+	Version      uint              `gorm:"not null;default:1" json:"version"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
 }
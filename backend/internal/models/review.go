@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Interaction is a completed exchange between a listing's owner and another
+// user, its partner: a marketplace sale, a delivery or a shared cab ride.
+// Once the partner accepted it each side may review the other once; a cab
+// passenger is accepted by having joined the ride.
+type Interaction struct {
+	ID          uint         `gorm:"primaryKey;autoIncrement" json:"id"`
+	ListingType string       `gorm:"type:varchar(20);not null;uniqueIndex:idx_interaction_partner" json:"listing_type"`
+	ListingID   uint         `gorm:"not null;uniqueIndex:idx_interaction_partner" json:"listing_id"`
+	OwnerID     *string      `gorm:"index" json:"owner_id"` // nil once the account is deleted
+	Owner       *UserSummary `gorm:"foreignKey:OwnerID;constraint:OnDelete:SET NULL" json:"owner,omitempty"`
+	PartnerID   *string      `gorm:"index;uniqueIndex:idx_interaction_partner" json:"partner_id"` // nil once the account is deleted
+	Partner     *UserSummary `gorm:"foreignKey:PartnerID;constraint:OnDelete:SET NULL" json:"partner,omitempty"`
+	Reviews     []Review     `gorm:"constraint:OnDelete:CASCADE" json:"reviews"`
+	AcceptedAt  *time.Time   `json:"accepted_at"` // nil until the partner accepts
+	CreatedAt   time.Time    `json:"created_at"`  // when the owner recorded it
+}
+
+// Review is one side's rating of the other after an interaction.
+type Review struct {
+	ID            uint         `gorm:"primaryKey;autoIncrement" json:"id"`
+	InteractionID uint         `gorm:"not null;uniqueIndex:idx_review_side" json:"interaction_id"`
+	ReviewerID    *string      `gorm:"index;uniqueIndex:idx_review_side" json:"reviewer_id"` // nil once the account is deleted
+	Reviewer      *UserSummary `gorm:"foreignKey:ReviewerID;constraint:OnDelete:SET NULL" json:"reviewer,omitempty"`
+	RevieweeID    string       `gorm:"not null;index" json:"reviewee_id"`
+	Reviewee      *UserSummary `gorm:"foreignKey:RevieweeID;constraint:OnDelete:CASCADE" json:"-"`
+	Rating        int          `gorm:"not null;check:rating BETWEEN 1 AND 5" json:"rating"` // 1 to 5 stars
+	Comment       string       `gorm:"type:varchar(1000);not null;default:''" json:"comment"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+// Reputation aggregates the ratings a user received in reviews. The sum is
+// kept so the average can be updated without rereading every review.
+type Reputation struct {
+	RatingCount   int     `gorm:"not null;default:0" json:"rating_count"`
+	RatingSum     int     `gorm:"not null;default:0" json:"-"`
+	RatingAverage float64 `gorm:"not null;default:0" json:"rating_average"` // 0 while unrated
+}
@@ -1,13 +1,177 @@
 package models
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Roles. Upload quotas are configured per role.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Genders a profile may give
+const (
+	GenderFemale = "female"
+	GenderMale   = "male"
+	GenderOther  = "other"
+)
+
+// Outcomes of an admin's review of a user's gender
+const (
+	GenderVerified = "verified"
+	GenderRejected = "rejected"
+)
 
 type User struct {
-	ID        string    `gorm:"primaryKey" json:"id"` // Clerk User ID
-	Email     string    `gorm:"unique;not null" json:"email"`
-	Username  string    `gorm:"unique" json:"username"`
-	AvatarURL string    `json:"avatar_url"`
+	ID        string `gorm:"primaryKey" json:"id"` // Clerk User ID
+	Email     string `gorm:"unique;not null" json:"email"`
+	Username  string `gorm:"unique" json:"username"`
+	AvatarURL string `json:"avatar_url"`
+	Role      string `gorm:"type:varchar(20);not null;default:'user'" json:"role"`
+
+	// Set once the user proves they own Email (a campus address) with a
+	// one-time code; cleared when Email changes. Required for all writes.
+	Verified   bool       `gorm:"not null;default:false" json:"verified"`
+	VerifiedAt *time.Time `json:"verified_at"`
+
+	// Profile; empty means not given. Hostel and Programme are codes from the
+	// admin-managed Hostel and Programme lists, Block one of the hostel's blocks.
+	Hostel    string         `gorm:"type:varchar(20);not null;default:''" json:"hostel,omitempty"`
+	Block     string         `gorm:"type:varchar(20);not null;default:''" json:"block,omitempty"`
+	Room      string         `gorm:"type:varchar(20);not null;default:''" json:"room,omitempty"`
+	Year      int            `gorm:"not null;default:0" json:"year,omitempty"` // year of study, 1 is first year
+	Programme string         `gorm:"type:varchar(20);not null;default:''" json:"programme,omitempty"`
+	Gender    string         `gorm:"type:varchar(10);not null;default:''" json:"gender,omitempty"`
+	Phone     string         `gorm:"type:varchar(20);not null;default:''" json:"phone,omitempty"`
+	Privacy   ProfilePrivacy `gorm:"type:jsonb" json:"privacy,omitempty"` // only shown to the user themselves
+
+	// Giving a gender is the user's attestation that it is true; an admin
+	// may then verify or reject it. Female-only cabs depend on it.
+	GenderAttestedAt *time.Time `json:"gender_attested_at,omitempty"`
+	GenderReview     string     `gorm:"type:varchar(10);not null;default:''" json:"gender_review,omitempty"` // "", verified or rejected
+	GenderReviewedBy string     `gorm:"type:varchar(100);not null;default:''" json:"gender_reviewed_by,omitempty"`
+	GenderReviewedAt *time.Time `json:"gender_reviewed_at,omitempty"`
+
+	// Set while a deletion the user asked for is pending; the account and
+	// its data are deleted at this time unless the user cancels first
+	DeleteAfter *time.Time `gorm:"index" json:"delete_after,omitempty"`
+
+	// Ratings received in reviews after interactions; public
+	Reputation
+
+	// Clerk's updated_at (ms) of the last synced webhook, to drop stale deliveries
+	ClerkUpdatedAt int64 `gorm:"not null;default:0" json:"-"`
 
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
+
+// UserSummary is the public part of a user shown as the owner of a listing.
+type UserSummary struct {
+	ID        string `json:"id"`
+	Username  string `json:"username"`
+	AvatarURL string `json:"avatar_url"`
+	Reputation
+}
+
+func (UserSummary) TableName() string { return "users" }
+
+// Who may see a profile field, from most to least open
+const (
+	VisibilityPublic  = "public"  // anyone
+	VisibilityCampus  = "campus"  // signed-in users with a verified campus email
+	VisibilityPrivate = "private" // only the user (and admins)
+)
+
+var visibilityRank = map[string]int{VisibilityPublic: 0, VisibilityCampus: 1, VisibilityPrivate: 2}
+
+// DefaultPrivacy applies to profile fields the user has not set a visibility
+// for. "hostel" covers both hostel and block.
+var DefaultPrivacy = ProfilePrivacy{
+	"hostel":    VisibilityCampus,
+	"room":      VisibilityPrivate,
+	"year":      VisibilityPublic,
+	"programme": VisibilityPublic,
+	"gender":    VisibilityPrivate,
+	"phone":     VisibilityPrivate,
+}
+
+// ProfilePrivacy maps profile fields to their visibility.
+type ProfilePrivacy map[string]string
+
+func (p ProfilePrivacy) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(p)
+	return string(b), err
+}
+
+func (p *ProfilePrivacy) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*p = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, p)
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	}
+	return errors.New("unsupported privacy value")
+}
+
+// EffectivePrivacy is the visibility of every profile field, defaults included.
+func (u *User) EffectivePrivacy() ProfilePrivacy {
+	out := ProfilePrivacy{}
+	for f, v := range DefaultPrivacy {
+		out[f] = v
+		if set, ok := u.Privacy[f]; ok {
+			out[f] = set
+		}
+	}
+	return out
+}
+
+// HideFrom clears the profile fields an audience (a visibility level: what
+// the viewer is allowed to see) may not see, and the privacy settings.
+func (u *User) HideFrom(audience string) {
+	privacy := u.EffectivePrivacy()
+	hidden := func(field string) bool {
+		return visibilityRank[privacy[field]] > visibilityRank[audience]
+	}
+	if hidden("hostel") {
+		u.Hostel, u.Block = "", ""
+	}
+	if hidden("room") {
+		u.Room = ""
+	}
+	if hidden("year") {
+		u.Year = 0
+	}
+	if hidden("programme") {
+		u.Programme = ""
+	}
+	if hidden("gender") {
+		u.Gender = ""
+		u.GenderAttestedAt, u.GenderReview, u.GenderReviewedBy, u.GenderReviewedAt = nil, "", "", nil
+	}
+	if hidden("phone") {
+		u.Phone = ""
+	}
+	u.Privacy = nil
+	u.DeleteAfter = nil
+}
+
+// CanRideFemaleOnly reports whether the user may see and join female-only
+// cabs: their attested gender is female and no admin rejected it. With
+// needsReview an admin must also have verified it.
+func (u *User) CanRideFemaleOnly(needsReview bool) bool {
+	if u.Gender != GenderFemale || u.GenderAttestedAt == nil || u.GenderReview == GenderRejected {
+		return false
+	}
+	return !needsReview || u.GenderReview == GenderVerified
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// EmailVerification is the pending one-time code of a user; there is at most
+// one per user and sending a new code replaces it. Only a hash of the code
+// is stored. Wrong guesses are counted per user, not per code, so
+// requesting a new code does not reset them.
+type EmailVerification struct {
+	UserID        string    `gorm:"primaryKey"`
+	Email         string    `gorm:"not null"` // the address the code was sent to
+	CodeHash      string    `gorm:"not null"`
+	Attempts      int       `gorm:"not null;default:0"`
+	AttemptsSince time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"` // start of the window Attempts counts
+	SentAt        time.Time `gorm:"not null"`
+	ExpiresAt     time.Time `gorm:"not null"`
+}
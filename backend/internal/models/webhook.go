@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// WebhookEvent records a processed delivery by its Svix message id, so
+// retries are not applied twice. user.deleted events also serve as
+// tombstones that stop late user.updated deliveries from recreating a user.
+type WebhookEvent struct {
+	ID        string    `gorm:"primaryKey;type:varchar(100)" json:"id"`
+	Type      string    `gorm:"type:varchar(50);not null" json:"type"`
+	ObjectID  string    `gorm:"index;not null;default:''" json:"object_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,146 @@
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+)
+
+//go:embed openapi.yaml
+var specYAML []byte
+
+// Load parses and validates the embedded OpenAPI document.
+func Load() (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(specYAML)
+	if err != nil {
+		return nil, fmt.Errorf("parse openapi spec: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid openapi spec: %w", err)
+	}
+	return doc, nil
+}
+
+// Register serves the spec at /openapi.json and a Swagger UI page at /docs.
+func Register(r gin.IRouter, doc *openapi3.T) {
+	r.GET("/openapi.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, doc)
+	})
+	r.GET("/docs", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage))
+	})
+}
+
+// Validator rejects requests whose path, query or body do not match the spec.
+// Routes that are not in the spec are passed through for gin to 404.
+func Validator(doc *openapi3.T) (gin.HandlerFunc, error) {
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("build openapi router: %w", err)
+	}
+
+	options := &openapi3filter.Options{
+		MultiError:         true,
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+	return func(c *gin.Context) {
+		route, pathParams, err := router.FindRoute(c.Request)
+		if err != nil {
+			if errors.Is(err, routers.ErrPathNotFound) || errors.Is(err, routers.ErrMethodNotAllowed) {
+				c.Next()
+				return
+			}
+			apierror.Abort(c, apierror.Validation(err.Error()))
+			return
+		}
+
+		err = openapi3filter.ValidateRequest(c.Request.Context(), &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    options,
+		})
+		if err != nil {
+			var fields []apierror.FieldError
+			collectFieldErrors(err, "", &fields)
+			apierror.Abort(c, apierror.Validation("Request does not match the API specification", fields...))
+			return
+		}
+
+		c.Next()
+	}, nil
+}
+
+// Flattens kin-openapi errors into one entry per offending parameter or body field.
+func collectFieldErrors(err error, field string, out *[]apierror.FieldError) {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		for _, inner := range e {
+			collectFieldErrors(inner, field, out)
+		}
+	case *openapi3filter.RequestError:
+		if e.Parameter != nil {
+			field = e.Parameter.Name
+		} else if e.RequestBody != nil && field == "" {
+			field = "body"
+		}
+		if e.Err != nil {
+			collectFieldErrors(e.Err, field, out)
+			return
+		}
+		*out = append(*out, apierror.FieldError{Field: field, Message: e.Reason})
+	case *openapi3.SchemaError:
+		if pointer := e.JSONPointer(); len(pointer) > 0 && field == "body" {
+			field = strings.Join(pointer, ".")
+		}
+		*out = append(*out, apierror.FieldError{Field: field, Message: e.Reason})
+	default:
+		*out = append(*out, apierror.FieldError{Field: field, Message: err.Error()})
+	}
+}
+
+var ginParam = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// MissingRoutes lists registered gin routes that have no matching operation in the spec.
+func MissingRoutes(doc *openapi3.T, routes gin.RoutesInfo) []string {
+	var missing []string
+	for _, route := range routes {
+		path := ginParam.ReplaceAllString(route.Path, "{$1}")
+		item := doc.Paths.Value(path)
+		if item == nil || item.GetOperation(route.Method) == nil {
+			missing = append(missing, route.Method+" "+route.Path)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>VIT Buddy API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
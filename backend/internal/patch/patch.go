@@ -0,0 +1,148 @@
+// Package patch applies RFC 7396 JSON merge patches to request DTOs.
+//
+// A patch is a JSON object whose members replace the matching fields; a
+// member set to null clears the field. Only fields in the resource's
+// allowlist may appear, and null is only accepted for nullable fields.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/validation"
+)
+
+// Field describes one mutable field, keyed by its JSON name (which is also the column name).
+type Field struct {
+	Nullable bool // null resets the column to its zero value
+}
+
+// Allowlist maps JSON field names to their patch rules.
+type Allowlist map[string]Field
+
+// ErrNotObject is returned when the patch document is not a JSON object.
+var ErrNotObject = errors.New("merge patch must be a JSON object")
+
+// Apply decodes the patch in body into dst (a pointer to a DTO struct with
+// json and binding tags), validates only the patched fields and returns the
+// column updates to hand to GORM.
+// Field problems are returned as an *apierror.Error listing every bad field.
+func Apply(body []byte, dst any, allowed Allowlist) (map[string]any, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(body, &doc); err != nil || doc == nil {
+		if err == nil || isNotObject(err) {
+			return nil, ErrNotObject
+		}
+		return nil, err
+	}
+
+	v := reflect.ValueOf(dst).Elem()
+	fieldsByJSON := jsonFields(v.Type())
+
+	keys := make([]string, 0, len(doc))
+	for k := range doc {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var problems []apierror.FieldError
+	updates := map[string]any{}
+	var structFields []string
+
+	for _, key := range keys {
+		raw := doc[key]
+		rule, ok := allowed[key]
+		sf, known := fieldsByJSON[key]
+		if !ok || !known {
+			problems = append(problems, apierror.FieldError{Field: key, Message: key + " cannot be modified"})
+			continue
+		}
+
+		target := v.FieldByIndex(sf.Index)
+		if string(bytes.TrimSpace(raw)) == "null" {
+			if !rule.Nullable {
+				problems = append(problems, apierror.FieldError{Field: key, Message: key + " cannot be null"})
+				continue
+			}
+			target.SetZero()
+			updates[key] = target.Interface()
+			continue
+		}
+
+		val := reflect.New(sf.Type)
+		if err := json.Unmarshal(raw, val.Interface()); err != nil {
+			problems = append(problems, apierror.FieldError{Field: key, Message: typeMessage(key, sf.Type, err)})
+			continue
+		}
+		target.Set(val.Elem())
+		updates[key] = target.Interface()
+		structFields = append(structFields, sf.Name)
+	}
+
+	// Nulls skip validation: clearing an optional field is always allowed
+	if len(structFields) > 0 {
+		if engine, ok := binding.Validator.Engine().(*validator.Validate); ok {
+			if err := engine.StructPartial(dst, structFields...); err != nil {
+				fields, ok := validation.FieldErrors(err)
+				if !ok {
+					return nil, err
+				}
+				problems = append(problems, fields...)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, apierror.Validation("Invalid fields", problems...)
+	}
+	if len(updates) == 0 {
+		return nil, apierror.Validation("No valid fields to update")
+	}
+	return updates, nil
+}
+
+func isNotObject(err error) bool {
+	var typeErr *json.UnmarshalTypeError
+	return errors.As(err, &typeErr)
+}
+
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+func typeMessage(key string, t reflect.Type, err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		switch t.Kind() {
+		case reflect.String:
+			return key + " must be a string"
+		case reflect.Bool:
+			return key + " must be a boolean"
+		case reflect.Int, reflect.Int64, reflect.Uint:
+			return key + " must be an integer"
+		case reflect.Float64:
+			return key + " must be a number"
+		}
+	}
+	if t.String() == "time.Time" {
+		return key + " must be an RFC 3339 timestamp"
+	}
+	return fmt.Sprintf("%s is invalid: %v", key, err)
+}
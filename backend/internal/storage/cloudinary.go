@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+
+	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+)
+
+// Cloudinary stores images in a folder of a Cloudinary account.
+type Cloudinary struct {
+	cld    *cloudinary.Cloudinary
+	folder string
+}
+
+func NewCloudinary(cloudName, apiKey, apiSecret, folder string) (*Cloudinary, error) {
+	cld, err := cloudinary.NewFromParams(cloudName, apiKey, apiSecret)
+	if err != nil {
+		return nil, err
+	}
+	return &Cloudinary{cld: cld, folder: folder}, nil
+}
+
+func (s *Cloudinary) Driver() string { return "cloudinary" }
+
+// Cloudinary public IDs carry no extension; the format is detected from the upload
+func (s *Cloudinary) publicID(key string) string {
+	return path.Join(s.folder, withoutExt(key))
+}
+
+func (s *Cloudinary) Put(ctx context.Context, key string, r io.Reader, _ int64, _ string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	overwrite := false
+	resp, err := s.cld.Upload.Upload(ctx, r, uploader.UploadParams{
+		PublicID:  s.publicID(key),
+		Overwrite: &overwrite,
+	})
+	if err != nil {
+		return "", err
+	}
+	// API failures come back in the result, not as an error
+	if resp.Error.Message != "" {
+		return "", errors.New("cloudinary: " + resp.Error.Message)
+	}
+	return resp.SecureURL, nil
+}
+
+func (s *Cloudinary) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	resp, err := s.cld.Upload.Destroy(ctx, uploader.DestroyParams{PublicID: s.publicID(key)})
+	if err != nil {
+		return err
+	}
+	if resp.Error.Message != "" {
+		return errors.New("cloudinary: " + resp.Error.Message)
+	}
+	// "not found" means it is already gone
+	return nil
+}
+
+// Ping checks the credentials as well as connectivity
+func (s *Cloudinary) Check(ctx context.Context) error {
+	resp, err := s.cld.Admin.Ping(ctx)
+	if err != nil {
+		return err
+	}
+	if resp.Error.Message != "" {
+		return errors.New("cloudinary: " + resp.Error.Message)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Local keeps files in a directory that the backend itself serves at URLPath.
+type Local struct {
+	Dir     string // where files are written
+	URLPath string // route the directory is served on, e.g. "/uploads"
+	BaseURL string // public URL of URLPath, e.g. "https://api.example.com/uploads"
+}
+
+func NewLocal(dir, urlPath, baseURL string) *Local {
+	return &Local{Dir: dir, URLPath: urlPath, BaseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+func (l *Local) Driver() string { return "local" }
+
+func (l *Local) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	// O_EXCL: keys are random, so an existing file means a bug, not an update
+	f, err := os.OpenFile(filepath.Join(l.Dir, key), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return l.BaseURL + "/" + key, nil
+}
+
+func (l *Local) Delete(_ context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	err := os.Remove(filepath.Join(l.Dir, key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Creates the directory if needed and proves it is writable
+func (l *Local) Check(_ context.Context) error {
+	if err := os.MkdirAll(l.Dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(l.Dir, ".check-*")
+	if err != nil {
+		return fmt.Errorf("upload directory %s is not writable: %w", l.Dir, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
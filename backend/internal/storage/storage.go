@@ -0,0 +1,56 @@
+// Package storage abstracts where uploaded images live. The driver is chosen
+// at startup (see config.InitStorage); handlers only see the Store interface.
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Store saves objects under server-generated keys and returns their public URL.
+type Store interface {
+	// Driver names the backend, e.g. "local", for logs and traces.
+	Driver() string
+	// Put stores size bytes from r under key and returns the URL clients load it from.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (url string, err error)
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+	// Check verifies the backend is reachable and writable; called at startup.
+	Check(ctx context.Context) error
+}
+
+// ErrInvalidKey is returned for keys that are not of the form NewKey generates.
+var ErrInvalidKey = errors.New("invalid storage key")
+
+var keyPattern = regexp.MustCompile(`^[a-z0-9]+(\.[a-z0-9]+)?$`)
+
+// NewKey returns a random object key keeping ext (e.g. ".jpg") if it is safe.
+func NewKey(ext string) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	key := hex.EncodeToString(b)
+	if ext = strings.ToLower(ext); ext != "" && keyPattern.MatchString("x"+ext) {
+		key += ext
+	}
+	return key
+}
+
+// Keys never contain slashes or dots other than the extension, so they are
+// safe to use as file names and object names on every backend.
+func validateKey(key string) error {
+	if !keyPattern.MatchString(key) {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return nil
+}
+
+func withoutExt(key string) string {
+	return strings.TrimSuffix(key, path.Ext(key))
+}
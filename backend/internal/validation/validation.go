@@ -0,0 +1,150 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/shreyashsri79/vitbuddy-backend/internal/apierror"
+)
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9]{10,15}$`)
+
+// CampusDomains are the email domains accepted by the campus_email rule.
+// Overridden with a comma-separated CAMPUS_EMAIL_DOMAINS.
+var CampusDomains = []string{"vitstudent.ac.in", "vit.ac.in"}
+
+// Register installs the custom rules on gin's validator and makes field
+// errors use JSON names. Must run before any request is bound.
+func Register() error {
+	if v := os.Getenv("CAMPUS_EMAIL_DOMAINS"); v != "" {
+		CampusDomains = nil
+		for _, d := range strings.Split(v, ",") {
+			if d = strings.ToLower(strings.TrimSpace(d)); d != "" {
+				CampusDomains = append(CampusDomains, d)
+			}
+		}
+	}
+
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("gin validator is not go-playground/validator")
+	}
+
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+
+	rules := map[string]validator.Func{
+		"phone":        isPhone,
+		"campus_email": isCampusEmail,
+		"future_date":  isFutureDate,
+	}
+	for tag, fn := range rules {
+		if err := v.RegisterValidation(tag, fn); err != nil {
+			return fmt.Errorf("register %s: %w", tag, err)
+		}
+	}
+	return nil
+}
+
+// Digits with an optional leading +, 10 to 15 long
+func isPhone(fl validator.FieldLevel) bool {
+	return phonePattern.MatchString(fl.Field().String())
+}
+
+func isCampusEmail(fl validator.FieldLevel) bool {
+	return IsCampusEmail(fl.Field().String())
+}
+
+// IsCampusEmail reports whether email belongs to one of CampusDomains.
+func IsCampusEmail(email string) bool {
+	local, domain, ok := strings.Cut(strings.ToLower(strings.TrimSpace(email)), "@")
+	if !ok || local == "" || strings.Contains(domain, "@") {
+		return false
+	}
+	for _, d := range CampusDomains {
+		if domain == d {
+			return true
+		}
+	}
+	return false
+}
+
+// Today or later, judged by calendar day in the value's own time zone so a
+// ride later today stays valid.
+func isFutureDate(fl validator.FieldLevel) bool {
+	t, ok := fl.Field().Interface().(time.Time)
+	if !ok {
+		return false
+	}
+	today := time.Now().In(t.Location()).Format(time.DateOnly)
+	return t.Format(time.DateOnly) >= today
+}
+
+// FieldErrors converts validator errors into one entry per failing field.
+// ok is false when err is not a validation error (e.g. malformed JSON).
+func FieldErrors(err error) (fields []apierror.FieldError, ok bool) {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil, false
+	}
+	for _, fe := range verrs {
+		fields = append(fields, apierror.FieldError{
+			Field:   fieldPath(fe),
+			Message: message(fe),
+		})
+	}
+	return fields, true
+}
+
+// Namespace without the top-level struct name, e.g. "from_location"
+func fieldPath(fe validator.FieldError) string {
+	if _, rest, ok := strings.Cut(fe.Namespace(), "."); ok {
+		return rest
+	}
+	return fe.Field()
+}
+
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return fe.Field() + " is required"
+	case "phone":
+		return fe.Field() + " must be a phone number of 10-15 digits"
+	case "campus_email":
+		return fe.Field() + " must be a campus email (" + strings.Join(CampusDomains, ", ") + ")"
+	case "future_date":
+		return fe.Field() + " cannot be in the past"
+	case "email":
+		return fe.Field() + " must be a valid email"
+	case "url":
+		return fe.Field() + " must be a valid URL"
+	case "oneof":
+		return fe.Field() + " must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "min", "gte":
+		if fe.Kind() == reflect.String {
+			return fe.Field() + " must be at least " + fe.Param() + " characters"
+		}
+		return fe.Field() + " must be at least " + fe.Param()
+	case "max", "lte":
+		if fe.Kind() == reflect.String {
+			return fe.Field() + " must be at most " + fe.Param() + " characters"
+		}
+		return fe.Field() + " must be at most " + fe.Param()
+	}
+	return fe.Field() + " is invalid (" + fe.Tag() + ")"
+}
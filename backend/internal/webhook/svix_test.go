@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const (
+	testSecret  = "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"
+	otherSecret = "whsec_c2VjcmV0LWZyb20tYmVmb3JlLXJvdGF0aW9u"
+)
+
+func mustSvix(t *testing.T, secret string) *Svix {
+	t.Helper()
+	s, err := NewSvix(secret)
+	if err != nil {
+		t.Fatalf("NewSvix: %v", err)
+	}
+	return s
+}
+
+func headers(id string, ts time.Time, sig string) http.Header {
+	h := http.Header{}
+	h.Set("svix-id", id)
+	h.Set("svix-timestamp", strconv.FormatInt(ts.Unix(), 10))
+	h.Set("svix-signature", sig)
+	return h
+}
+
+func TestVerify(t *testing.T) {
+	s := mustSvix(t, testSecret)
+	old := mustSvix(t, otherSecret)
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"type":"user.created","data":{"id":"user_1"}}`)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		body   []byte
+		want   error
+	}{
+		{"valid", headers("msg_1", now, s.Sign("msg_1", now, body)), body, nil},
+		{"tampered body", headers("msg_1", now, s.Sign("msg_1", now, body)),
+			[]byte(`{"type":"user.created","data":{"id":"user_2"}}`), ErrSignature},
+		{"other message id", headers("msg_2", now, s.Sign("msg_1", now, body)), body, ErrSignature},
+		{"wrong secret", headers("msg_1", now, old.Sign("msg_1", now, body)), body, ErrSignature},
+		{"stale timestamp", headers("msg_1", now.Add(-Tolerance-time.Second), s.Sign("msg_1", now.Add(-Tolerance-time.Second), body)), body, ErrTimestamp},
+		{"future timestamp", headers("msg_1", now.Add(Tolerance+time.Second), s.Sign("msg_1", now.Add(Tolerance+time.Second), body)), body, ErrTimestamp},
+		{"timestamp at the tolerance", headers("msg_1", now.Add(-Tolerance), s.Sign("msg_1", now.Add(-Tolerance), body)), body, nil},
+		{"rotated secrets, new one last", headers("msg_1", now, old.Sign("msg_1", now, body)+" "+s.Sign("msg_1", now, body)), body, nil},
+		{"rotated secrets, new one first", headers("msg_1", now, s.Sign("msg_1", now, body)+" "+old.Sign("msg_1", now, body)), body, nil},
+		{"unknown versions skipped", headers("msg_1", now, "v1a,abc v2,"+s.Sign("msg_1", now, body)[3:]+" "+s.Sign("msg_1", now, body)), body, nil},
+		{"only unknown versions", headers("msg_1", now, "v2,"+s.Sign("msg_1", now, body)[3:]), body, ErrSignature},
+		{"malformed signature", headers("msg_1", now, "v1,not-base64!"), body, ErrSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.Verify(tt.header, tt.body, now); !errors.Is(err, tt.want) {
+				t.Errorf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyMissingOrInvalidHeaders(t *testing.T) {
+	s := mustSvix(t, testSecret)
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{}`)
+	valid := headers("msg_1", now, s.Sign("msg_1", now, body))
+
+	for _, name := range []string{"svix-id", "svix-timestamp", "svix-signature"} {
+		t.Run("without "+name, func(t *testing.T) {
+			h := valid.Clone()
+			h.Del(name)
+			if err := s.Verify(h, body, now); !errors.Is(err, ErrMissingHeaders) {
+				t.Errorf("Verify = %v, want %v", err, ErrMissingHeaders)
+			}
+		})
+	}
+
+	t.Run("non-numeric timestamp", func(t *testing.T) {
+		h := valid.Clone()
+		h.Set("svix-timestamp", "yesterday")
+		if err := s.Verify(h, body, now); !errors.Is(err, ErrTimestamp) {
+			t.Errorf("Verify = %v, want %v", err, ErrTimestamp)
+		}
+	})
+}
+
+func TestNewSvixRejectsBadSecrets(t *testing.T) {
+	for _, secret := range []string{"", "whsec_", "whsec_not base64"} {
+		if _, err := NewSvix(secret); err == nil {
+			t.Errorf("NewSvix(%q) succeeded", secret)
+		}
+	}
+}